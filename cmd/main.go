@@ -2,11 +2,14 @@ package main
 
 import (
 	"log"
-	"net/http"
 
 	"github.com/senyabanana/library-service/internal/app"
 )
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+// @description Ключ в формате "ApiKey <ключ>"
 func main() {
 	application, err := app.InitializeApp()
 	if err != nil {
@@ -14,5 +17,5 @@ func main() {
 	}
 
 	application.Logger.Info("Server is running on :8080")
-	application.Logger.WithError(err).Fatal(http.ListenAndServe(":8080", nil))
+	application.Logger.Fatal(application.Router.ListenAndServe())
 }
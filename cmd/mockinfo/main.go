@@ -0,0 +1,69 @@
+// Command mockinfo serves the /info API of the music service from fixture
+// files for local development.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/mockinfo"
+
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	var opts mockinfo.Options
+	addr := flag.String("addr", ":8081", "address to listen on")
+	fixtures := flag.String("fixtures", "", "JSON file or directory of JSON files with songs (bundled fixtures if empty)")
+	flag.DurationVar(&opts.Latency, "latency", 0, "delay added to every response")
+	flag.DurationVar(&opts.Jitter, "jitter", 0, "random delay added on top of latency, up to this value")
+	flag.Float64Var(&opts.ErrorRate, "error-rate", 0, "share of requests answered with 500")
+	flag.Float64Var(&opts.NotFoundRate, "not-found-rate", 0, "share of requests answered with 404")
+	flag.Float64Var(&opts.MalformedRate, "malformed-rate", 0, "share of requests answered with truncated JSON")
+	flag.Int64Var(&opts.Seed, "seed", 0, "random seed for injected failures (clock if 0)")
+	flag.Parse()
+
+	logg := logger.NewLogger()
+
+	entries, err := mockinfo.LoadFixtures(*fixtures)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+	handler, err := mockinfo.New(entries, opts)
+	if err != nil {
+		log.Fatalf("Invalid options: %v", err)
+	}
+
+	logg.WithFields(logrus.Fields{
+		"addr":  *addr,
+		"songs": len(entries),
+	}).Info("Mock music info server is running")
+	logg.Fatal(http.ListenAndServe(*addr, logRequests(handler, logg)))
+}
+
+func logRequests(next http.Handler, logg *logger.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		logg.WithFields(logrus.Fields{
+			"method":   r.Method,
+			"url":      r.URL.String(),
+			"status":   rec.status,
+			"duration": time.Since(start),
+		}).Debug("Request served")
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
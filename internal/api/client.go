@@ -1,9 +1,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/senyabanana/library-service/internal/entities"
 	"github.com/senyabanana/library-service/internal/logger"
@@ -11,46 +15,76 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrNotFound is returned when the API does not know the song.
+var ErrNotFound = errors.New("song not found in music API")
+
+// MusicAPIClient requests the external /info API. All requests, from
+// handlers and background jobs alike, share the client's limiter.
 type MusicAPIClient struct {
 	baseURL string
+	client  *http.Client
+	limiter *Limiter
 	logg    *logger.Logger
 }
 
-func NewMusicAPIClient(baseURL string, logg *logger.Logger) *MusicAPIClient {
+func NewMusicAPIClient(baseURL string, limiter *Limiter, logg *logger.Logger) *MusicAPIClient {
 	return &MusicAPIClient{
 		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		limiter: limiter,
 		logg:    logg,
 	}
 }
 
-func (c *MusicAPIClient) FetchSongDetails(group, song string) (*entities.Song, error) {
-	url := fmt.Sprintf("%s/info?group=%s&song=%s", c.baseURL, group, song)
+// GetDetails requests /info for a song. A 404 answer is reported as
+// ErrNotFound.
+func (c *MusicAPIClient) GetDetails(ctx context.Context, group, song string) (entities.Details, error) {
+	query := url.Values{"group": {group}, "song": {song}}
+	url := fmt.Sprintf("%s/info?%s", c.baseURL, query.Encode())
 	c.logg.WithFields(logrus.Fields{
 		"url":   url,
 		"group": group,
 		"song":  song,
 	}).Debug("Fetching song details from API")
 
-	resp, err := http.Get(url)
+	start := time.Now()
+	if err := c.limiter.Wait(ctx); err != nil {
+		return entities.Details{}, err
+	}
+	if waited := time.Since(start); waited > time.Second {
+		c.logg.WithFields(logrus.Fields{
+			"priority": priorityFrom(ctx).String(),
+			"waited":   waited,
+		}).Debug("Request to API was queued by the rate limiter")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return entities.Details{}, err
+	}
+	resp, err := c.client.Do(req)
 	if err != nil {
 		c.logg.WithError(err).Error("Failed to send request to API")
-		return nil, err
+		return entities.Details{}, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return entities.Details{}, ErrNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		err := fmt.Errorf("failed to fetch song details: %s", resp.Status)
 		c.logg.WithFields(logrus.Fields{
 			"url":         url,
 			"status_code": resp.StatusCode,
 		}).Error(err.Error())
-		return nil, err
+		return entities.Details{}, err
 	}
 
 	var details entities.Details
 	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
 		c.logg.WithError(err).Error("Failed to decode response from API")
-		return nil, err
+		return entities.Details{}, err
 	}
 
 	c.logg.WithFields(logrus.Fields{
@@ -58,12 +92,5 @@ func (c *MusicAPIClient) FetchSongDetails(group, song string) (*entities.Song, e
 		"song":        song,
 		"releaseDate": details.ReleaseDate,
 	}).Info("Fetched song details successfully")
-
-	return &entities.Song{
-		GroupName:   group,
-		SongName:    song,
-		ReleaseDate: details.ReleaseDate,
-		Text:        details.Text,
-		Link:        details.Link,
-	}, nil
+	return details, nil
 }
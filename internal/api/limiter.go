@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+// Priority is the lane a request to the API queues in. When tokens are
+// short, interactive requests are served before batch ones.
+type Priority int
+
+const (
+	PriorityInteractive Priority = iota
+	PriorityBatch
+	priorityCount
+)
+
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "interactive"
+}
+
+type priorityKey struct{}
+
+// WithPriority marks the requests made with ctx, e.g. as PriorityBatch for
+// background jobs. Requests are interactive by default.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < priorityCount {
+		return p
+	}
+	return PriorityInteractive
+}
+
+// Limiter is a token bucket refilled at rps tokens a second up to burst.
+// Requests that find it empty queue in their priority lane and are served
+// first come, first served within a lane. A nil Limiter does not limit.
+type Limiter struct {
+	rps   float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	queues [priorityCount][]*waiter
+	timer  *time.Timer
+	lanes  [priorityCount]lane
+}
+
+type waiter struct {
+	since time.Time
+	// wait is set by dispatch before ready is closed.
+	wait  time.Duration
+	ready chan struct{}
+}
+
+type lane struct {
+	requests  int64
+	delayed   int64
+	cancelled int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+func NewLimiter(rps float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rps:    rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available for the priority of ctx or ctx is
+// done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	p := priorityFrom(ctx)
+
+	l.mu.Lock()
+	l.refill(time.Now())
+	if l.waiting() == 0 && l.tokens >= 1 {
+		l.tokens--
+		l.lanes[p].requests++
+		l.mu.Unlock()
+		return nil
+	}
+	w := &waiter{since: time.Now(), ready: make(chan struct{})}
+	l.queues[p] = append(l.queues[p], w)
+	l.dispatch()
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		lane := &l.lanes[p]
+		lane.requests++
+		lane.delayed++
+		lane.totalWait += w.wait
+		lane.maxWait = max(lane.maxWait, w.wait)
+		return nil
+	case <-ctx.Done():
+	}
+
+	// A cancelled waiter counts only as cancelled, not as a request.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lanes[p].cancelled++
+	select {
+	case <-w.ready:
+		// Granted just as ctx was done; hand the token on.
+		l.tokens = min(l.tokens+1, l.burst)
+		l.dispatch()
+	default:
+		for i, queued := range l.queues[p] {
+			if queued == w {
+				l.queues[p] = append(l.queues[p][:i], l.queues[p][i+1:]...)
+				break
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// Stats reports the limiter settings and per-lane wait times. A nil Limiter
+// reports no lanes.
+func (l *Limiter) Stats() entities.LimiterStats {
+	if l == nil {
+		return entities.LimiterStats{Lanes: map[string]entities.LaneStats{}}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+
+	stats := entities.LimiterStats{
+		RPS:    l.rps,
+		Burst:  int(l.burst),
+		Tokens: l.tokens,
+		Lanes:  map[string]entities.LaneStats{},
+	}
+	for p := range priorityCount {
+		lane := l.lanes[p]
+		s := entities.LaneStats{
+			Requests:  lane.requests,
+			Delayed:   lane.delayed,
+			Cancelled: lane.cancelled,
+			Queued:    len(l.queues[p]),
+			WaitMaxMs: milliseconds(lane.maxWait),
+		}
+		if lane.requests > 0 {
+			s.WaitAvgMs = milliseconds(lane.totalWait) / float64(lane.requests)
+		}
+		stats.Lanes[p.String()] = s
+	}
+	return stats
+}
+
+func (l *Limiter) refill(now time.Time) {
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rps, l.burst)
+	l.last = now
+}
+
+func (l *Limiter) waiting() int {
+	n := 0
+	for _, queue := range l.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// dispatch hands out the available tokens lane by lane and schedules
+// itself for when the next token is due. Called with mu held.
+func (l *Limiter) dispatch() {
+	now := time.Now()
+	l.refill(now)
+	for p := range l.queues {
+		for len(l.queues[p]) > 0 && l.tokens >= 1 {
+			w := l.queues[p][0]
+			l.queues[p] = l.queues[p][1:]
+			l.tokens--
+
+			w.wait = now.Sub(w.since)
+			close(w.ready)
+		}
+	}
+
+	if l.waiting() > 0 && l.timer == nil {
+		next := time.Duration((1 - l.tokens) / l.rps * float64(time.Second))
+		l.timer = time.AfterFunc(next, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.timer = nil
+			l.dispatch()
+		})
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
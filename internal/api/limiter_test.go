@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterBurst(t *testing.T) {
+	l := NewLimiter(1, 3)
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+
+	lane := l.Stats().Lanes[PriorityInteractive.String()]
+	if lane.Requests != 3 || lane.Delayed != 0 || lane.Cancelled != 0 {
+		t.Errorf("interactive lane = %+v, want 3 requests served from the burst", lane)
+	}
+}
+
+func TestLimiterServesInteractiveFirst(t *testing.T) {
+	l := NewLimiter(10, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	order := make(chan Priority, 2)
+	wait := func(p Priority) {
+		if err := l.Wait(WithPriority(context.Background(), p)); err != nil {
+			t.Errorf("Wait %s: %v", p, err)
+		}
+		order <- p
+	}
+	go wait(PriorityBatch)
+	waitQueued(t, l, PriorityBatch)
+	go wait(PriorityInteractive)
+	waitQueued(t, l, PriorityInteractive)
+
+	if first, second := <-order, <-order; first != PriorityInteractive || second != PriorityBatch {
+		t.Errorf("served %s then %s, want interactive before batch", first, second)
+	}
+
+	stats := l.Stats()
+	if batch := stats.Lanes[PriorityBatch.String()]; batch.Requests != 1 || batch.Delayed != 1 || batch.WaitMaxMs <= 0 {
+		t.Errorf("batch lane = %+v, want one delayed request", batch)
+	}
+	if interactive := stats.Lanes[PriorityInteractive.String()]; interactive.Requests != 2 || interactive.Delayed != 1 {
+		t.Errorf("interactive lane = %+v, want two requests, one delayed", interactive)
+	}
+}
+
+func TestLimiterCancelledWait(t *testing.T) {
+	l := NewLimiter(20, 1)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	// The cancelled waiter leaves the queue and does not take the next token.
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait after cancel: %v", err)
+	}
+
+	lane := l.Stats().Lanes[PriorityInteractive.String()]
+	if lane.Requests != 2 || lane.Delayed != 1 || lane.Cancelled != 1 || lane.Queued != 0 {
+		t.Errorf("interactive lane = %+v, want 2 requests, 1 delayed, 1 cancelled", lane)
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+	if stats := l.Stats(); stats.Lanes == nil || len(stats.Lanes) != 0 {
+		t.Errorf("Stats = %+v, want no lanes", stats)
+	}
+}
+
+func TestPriorityFrom(t *testing.T) {
+	if p := priorityFrom(context.Background()); p != PriorityInteractive {
+		t.Errorf("default priority = %s, want interactive", p)
+	}
+	if p := priorityFrom(WithPriority(context.Background(), PriorityBatch)); p != PriorityBatch {
+		t.Errorf("priority = %s, want batch", p)
+	}
+	if p := priorityFrom(WithPriority(context.Background(), Priority(7))); p != PriorityInteractive {
+		t.Errorf("unknown priority = %s, want interactive", p)
+	}
+}
+
+// waitQueued waits until a request is queued in the lane of p.
+func waitQueued(t *testing.T, l *Limiter, p Priority) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for l.Stats().Lanes[p.String()].Queued == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s request queued", p)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -1,12 +1,23 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/senyabanana/library-service/internal/api"
 	"github.com/senyabanana/library-service/internal/config"
+	"github.com/senyabanana/library-service/internal/enrichment"
 	"github.com/senyabanana/library-service/internal/handlers"
+	"github.com/senyabanana/library-service/internal/jobs"
+	"github.com/senyabanana/library-service/internal/links"
 	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/middleware"
+	"github.com/senyabanana/library-service/internal/profanity"
+	"github.com/senyabanana/library-service/internal/ratelimit"
 	"github.com/senyabanana/library-service/internal/repository"
 	"github.com/senyabanana/library-service/internal/router"
 	"github.com/senyabanana/library-service/internal/services"
@@ -30,7 +41,10 @@ func InitializeApp() (*App, error) {
 		logg.WithError(err).Fatal("Failed to load configuration")
 	}
 
-	logg.WithField("config", cfg).Info("Configuration loaded")
+	logg.WithField("config", cfg.Redacted()).Info("Configuration loaded")
+	if cfg.AdminAPIKey == "" {
+		logg.Warn("ADMIN_API_KEY is not set, API keys can only be managed with existing admin keys")
+	}
 
 	runDBMigration(cfg.MigrationURL, cfg.DBConn, logg)
 
@@ -38,12 +52,116 @@ func InitializeApp() (*App, error) {
 	if err != nil {
 		logg.WithError(err).Fatal("Failed to connect to database")
 	}
-	defer db.Close()
 
-	repo := repository.NewSongRepository(db, logg)
-	service := services.NewSongService(repo, logg)
+	auditRepo := repository.NewAuditRepository(db, logg)
+	auditService := services.NewAuditService(auditRepo, logg)
+	auditHandler := handlers.NewAuditHandler(auditService, logg)
+
+	repo := repository.NewSongRepository(db, auditRepo, logg)
+	revisionRepo := repository.NewRevisionRepository(db, logg)
+	lyricsRepo := repository.NewLyricsRepository(db, auditRepo, logg)
+	enrichmentRepo := repository.NewEnrichmentRepository(db, logg)
+	txManager := repository.NewTxManager(db, repository.Repositories{
+		Songs:      repo,
+		Revisions:  revisionRepo,
+		Audit:      auditRepo,
+		Enrichment: enrichmentRepo,
+	}, logg)
+	statsTTL := time.Duration(cfg.LyricsStatsTTLMinutes) * time.Minute
+	if statsTTL <= 0 {
+		statsTTL = 10 * time.Minute
+	}
+	statsCache := services.NewLyricsStatsCache(statsTTL)
+	dictionary, err := profanity.Load(cfg.ProfanityDictionary)
+	if err != nil {
+		logg.WithError(err).Fatal("Failed to load profanity dictionary")
+	}
+	apiRPS := cfg.MusicAPIRPS
+	if apiRPS <= 0 {
+		apiRPS = 5
+	}
+	apiBurst := cfg.MusicAPIBurst
+	if apiBurst <= 0 {
+		apiBurst = 10
+	}
+	apiLimiter := api.NewLimiter(apiRPS, apiBurst)
+	enricher, err := newEnrichmentPipeline(cfg, apiLimiter, logg)
+	if err != nil {
+		logg.WithError(err).Fatal("Failed to set up enrichment providers")
+	}
+	service := services.NewAuthorizedSongService(services.NewSongService(repo, revisionRepo, lyricsRepo, txManager, statsCache, dictionary, enricher, logg), logg)
 	handler := handlers.NewSongHandler(service, logg)
-	routes := router.SetupRoutes(handler, logg)
+	revisionService := services.NewRevisionService(revisionRepo, service, logg)
+	revisionHandler := handlers.NewRevisionHandler(revisionService, logg)
+
+	lyricsService := services.NewLyricsService(lyricsRepo, service, logg)
+	lyricsHandler := handlers.NewLyricsHandler(lyricsService, logg)
+	statsService := services.NewLyricsStatsService(service, repo, lyricsRepo, statsCache, logg)
+	statsHandler := handlers.NewStatsHandler(statsService, logg)
+
+	linkRepo := repository.NewLinkRepository(db, auditRepo, logg)
+	linkService := services.NewLinkService(linkRepo, service, logg)
+	linkHandler := handlers.NewLinkHandler(linkService, logg)
+
+	enrichmentService := services.NewEnrichmentService(enrichmentRepo, service, enricher, apiLimiter, logg)
+	enrichmentHandler := handlers.NewEnrichmentHandler(enrichmentService, logg)
+
+	importRepo := repository.NewImportRepository(db, auditRepo, logg)
+	importService := services.NewImportService(importRepo, statsCache, dictionary, logg)
+	importMaxMB := cfg.ImportMaxMB
+	if importMaxMB <= 0 {
+		importMaxMB = 100
+	}
+	importHandler := handlers.NewImportHandler(importService, int64(importMaxMB)<<20, logg)
+
+	keyRepo := repository.NewAPIKeyRepository(db, logg)
+	keyService := services.NewAPIKeyService(keyRepo, cfg.AdminAPIKey, logg)
+	keyHandler := handlers.NewAPIKeyHandler(keyService, logg)
+	authMW := middleware.NewAuthMiddleware(keyService, logg)
+
+	redirectMW := middleware.NewRedirectMiddleware(service, logg)
+
+	idempotencyTTL := time.Duration(cfg.IdempotencyTTLHours) * time.Hour
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = 24 * time.Hour
+	}
+	idempotencyRepo := repository.NewIdempotencyRepository(db, logg)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, idempotencyTTL, logg)
+	idempotencyMW := middleware.NewIdempotencyMiddleware(idempotencyService, logg)
+	go jobs.NewIdempotencyCleanupJob(idempotencyRepo, time.Hour, logg).Run(context.Background())
+
+	rateLimitMW, err := newRateLimitMiddleware(cfg, db, logg)
+	if err != nil {
+		logg.WithError(err).Fatal("Failed to set up rate limiting")
+	}
+
+	routes := router.SetupRoutes(handler, keyHandler, auditHandler, revisionHandler, importHandler, lyricsHandler, statsHandler, linkHandler, enrichmentHandler, authMW, redirectMW, idempotencyMW, rateLimitMW, logg)
+
+	go jobs.NewExplicitRescanJob(repo, lyricsRepo, dictionary, logg).Run(context.Background())
+
+	if cfg.EnrichmentRefreshDays > 0 {
+		staleAfter := time.Duration(cfg.EnrichmentRefreshDays) * 24 * time.Hour
+		batch := cfg.EnrichmentRefreshBatch
+		if batch <= 0 {
+			batch = 50
+		}
+		perMinute := cfg.EnrichmentRefreshPerMinute
+		if perMinute <= 0 {
+			perMinute = 60
+		}
+		go jobs.NewReEnrichmentJob(enrichmentRepo, enrichmentService, staleAfter, 10*time.Minute, batch, time.Minute/time.Duration(perMinute), logg).Run(context.Background())
+	}
+
+	if cfg.LinkCheckIntervalHours > 0 {
+		recheck := time.Duration(cfg.LinkCheckIntervalHours) * time.Hour
+		checker := links.NewChecker(nil, "library-service link checker")
+		go jobs.NewLinkCheckJob(linkRepo, checker, recheck, 10*time.Minute, logg).Run(context.Background())
+	}
+
+	if cfg.TrashRetentionDays > 0 {
+		retention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+		go jobs.NewTrashRetentionJob(repo, retention, time.Hour, logg).Run(context.Background())
+	}
 
 	return &App{
 		Router: &http.Server{
@@ -54,6 +172,92 @@ func InitializeApp() (*App, error) {
 	}, nil
 }
 
+// newEnrichmentPipeline sets up the enrichment providers listed in
+// ENRICHMENT_PROVIDERS in that order. Providers that need configuration
+// which is not given are skipped.
+func newEnrichmentPipeline(cfg *config.Config, limiter *api.Limiter, logg *logger.Logger) (*enrichment.Pipeline, error) {
+	names := cfg.EnrichmentProviders
+	if strings.TrimSpace(names) == "" {
+		names = "api,catalogue,placeholder"
+	}
+
+	var enrichers []enrichment.Enricher
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case enrichment.ProviderAPI:
+			if cfg.MusicAPIURL == "" {
+				logg.Warn("MUSIC_API_URL is not set, skipping api enrichment provider")
+				continue
+			}
+			enrichers = append(enrichers, enrichment.NewAPIProvider(api.NewMusicAPIClient(cfg.MusicAPIURL, limiter, logg)))
+		case enrichment.ProviderCatalogue:
+			if cfg.EnrichmentCatalogue == "" {
+				logg.Warn("ENRICHMENT_CATALOGUE is not set, skipping catalogue enrichment provider")
+				continue
+			}
+			catalogue, err := enrichment.LoadCatalogue(cfg.EnrichmentCatalogue)
+			if err != nil {
+				return nil, err
+			}
+			enrichers = append(enrichers, catalogue)
+		case enrichment.ProviderFixtures:
+			enrichers = append(enrichers, enrichment.Fixtures())
+		case enrichment.ProviderPlaceholder:
+			enrichers = append(enrichers, enrichment.Placeholder{})
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown enrichment provider %q", name)
+		}
+	}
+
+	rules, err := enrichment.ParseRules(cfg.EnrichmentRules)
+	if err != nil {
+		return nil, err
+	}
+	logg.WithField("providers", names).Info("Enrichment providers configured")
+	return enrichment.NewPipeline(enrichers, rules, logg), nil
+}
+
+// newRateLimitMiddleware sets up inbound rate limiting with the store named
+// in RATE_LIMIT_STORE. It returns nil when rate limiting is off.
+func newRateLimitMiddleware(cfg *config.Config, db *sql.DB, logg *logger.Logger) (*middleware.RateLimitMiddleware, error) {
+	name := cfg.RateLimitStore
+	if name == "" {
+		name = "memory"
+	}
+
+	var store ratelimit.Store
+	switch name {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = repository.NewRateLimitRepository(db, logg)
+	case "off":
+		logg.Warn("Rate limiting is off")
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q, expected memory, postgres or off", name)
+	}
+	go jobs.NewRateLimitCleanupJob(store, 10*time.Minute, logg).Run(context.Background())
+
+	perMinute := func(limit, fallback int) ratelimit.Rule {
+		if limit <= 0 {
+			limit = fallback
+		}
+		return ratelimit.Rule{Limit: limit, Window: time.Minute}
+	}
+	policy := ratelimit.Policy{
+		IPRead:     perMinute(cfg.RateLimitIPReadPerMinute, 120),
+		IPWrite:    perMinute(cfg.RateLimitIPWritePerMinute, 20),
+		IPAuth:     perMinute(cfg.RateLimitIPAuthPerMinute, 1200),
+		KeyRead:    perMinute(cfg.RateLimitKeyReadPerMinute, 600),
+		KeyWrite:   perMinute(cfg.RateLimitKeyWritePerMinute, 120),
+		DailyQuota: max(cfg.RateLimitKeyDailyQuota, 0),
+	}
+	logg.WithField("store", name).Info("Rate limiting configured")
+	return middleware.NewRateLimitMiddleware(ratelimit.NewLimiter(store), policy, cfg.RateLimitTrustProxy, logg), nil
+}
+
 func runDBMigration(migrationURL, dBSource string, logg *logger.Logger) {
 	migration, err := migrate.New(migrationURL, dBSource)
 	if err != nil {
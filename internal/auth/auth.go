@@ -0,0 +1,52 @@
+package auth
+
+import "context"
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+type Principal struct {
+	KeyID  int
+	Name   string
+	Scopes []string
+	Role   string
+	// DailyQuota is the key's own quota; nil uses the default.
+	DailyQuota *int
+}
+
+// HasScope reports whether the principal was granted scope. Scopes are
+// hierarchical: admin implies write, and write implies read.
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin || (s == ScopeWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
+
+func ValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+// ActorName identifies the caller in ctx for audit and history records.
+func ActorName(ctx context.Context) string {
+	if p, ok := PrincipalFromContext(ctx); ok {
+		return p.Name
+	}
+	return "anonymous"
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+type Permission string
+
+const (
+	PermSongsRead   Permission = "songs:read"
+	PermSongsWrite  Permission = "songs:write"
+	PermSongsDelete Permission = "songs:delete"
+	// PermSongsRestore allows listing the trash and restoring songs from it.
+	PermSongsRestore Permission = "songs:restore"
+	PermSongsImport  Permission = "songs:import"
+	PermSongsPurge   Permission = "songs:purge"
+	PermSongsMerge   Permission = "songs:merge"
+	// PermAdmin allows managing API keys and reading the audit log and the
+	// service internals.
+	PermAdmin Permission = "admin"
+)
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleViewer: {PermSongsRead},
+	RoleEditor: {PermSongsRead, PermSongsWrite, PermSongsRestore},
+	RoleAdmin:  {PermSongsRead, PermSongsWrite, PermSongsDelete, PermSongsRestore, PermSongsImport, PermSongsPurge, PermSongsMerge, PermAdmin},
+}
+
+var roleRank = map[string]int{RoleViewer: 0, RoleEditor: 1, RoleAdmin: 2}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func RolePermissions() map[string][]Permission {
+	return rolePermissions
+}
+
+// DefaultRole picks the role a key gets when none is requested explicitly,
+// so that a key is never granted more through its role than its scopes allow.
+func DefaultRole(scopes []string) string {
+	p := Principal{Scopes: scopes}
+	switch {
+	case p.HasScope(ScopeAdmin):
+		return RoleAdmin
+	case p.HasScope(ScopeWrite):
+		return RoleEditor
+	default:
+		return RoleViewer
+	}
+}
+
+// RoleAllowed reports whether a key with scopes may hold role. A role below
+// the scopes demotes the key; a role above them is not allowed.
+func RoleAllowed(role string, scopes []string) bool {
+	return roleRank[role] <= roleRank[DefaultRole(scopes)]
+}
+
+func (p *Principal) Can(perm Permission) bool {
+	for _, granted := range rolePermissions[p.Role] {
+		if granted == perm {
+			return true
+		}
+	}
+	return false
+}
+
+type PermissionError struct {
+	Permission Permission
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("forbidden: missing permission %s", e.Permission)
+}
+
+// Require checks that the caller in ctx holds perm. Anonymous callers are
+// treated as viewers.
+func Require(ctx context.Context, perm Permission) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		principal = &Principal{Name: "anonymous", Role: RoleViewer}
+	}
+	if !principal.Can(perm) {
+		return &PermissionError{Permission: perm}
+	}
+	return nil
+}
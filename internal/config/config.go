@@ -1,6 +1,10 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"net/url"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
 	DBHost       string `mapstructure:"DB_HOST"`
@@ -10,11 +14,85 @@ type Config struct {
 	DBName       string `mapstructure:"DB_NAME"`
 	DBConn       string `mapstructure:"DB_CONN"`
 	MigrationURL string `mapstructure:"MIGRATION_URL"`
+	AdminAPIKey  string `mapstructure:"ADMIN_API_KEY"`
+
+	// MusicAPIURL is the base URL of the external /info API; the api
+	// enrichment provider is skipped when it is empty.
+	MusicAPIURL string `mapstructure:"MUSIC_API_URL"`
+	// MusicAPIRPS and MusicAPIBurst limit the requests to the API across
+	// all callers.
+	MusicAPIRPS   float64 `mapstructure:"MUSIC_API_RPS"`
+	MusicAPIBurst int     `mapstructure:"MUSIC_API_BURST"`
+	// EnrichmentProviders lists enrichment providers by priority, from api,
+	// catalogue, fixtures and placeholder.
+	EnrichmentProviders string `mapstructure:"ENRICHMENT_PROVIDERS"`
+	// EnrichmentRules prefers a provider for a field, e.g. "text=catalogue".
+	EnrichmentRules string `mapstructure:"ENRICHMENT_RULES"`
+	// EnrichmentCatalogue is a JSON file of songs for the catalogue provider.
+	EnrichmentCatalogue string `mapstructure:"ENRICHMENT_CATALOGUE"`
+
+	// EnrichmentRefreshDays is the age after which enriched songs are looked
+	// up again; zero disables re-enrichment. At most EnrichmentRefreshBatch
+	// songs are refreshed per run, EnrichmentRefreshPerMinute a minute.
+	EnrichmentRefreshDays      int `mapstructure:"ENRICHMENT_REFRESH_DAYS"`
+	EnrichmentRefreshBatch     int `mapstructure:"ENRICHMENT_REFRESH_BATCH"`
+	EnrichmentRefreshPerMinute int `mapstructure:"ENRICHMENT_REFRESH_PER_MINUTE"`
+
+	// ProfanityDictionary is a JSON file of explicit words per language.
+	// The bundled dictionary is used when it is empty.
+	ProfanityDictionary string `mapstructure:"PROFANITY_DICTIONARY"`
+
+	// ImportMaxMB caps the size of an uploaded import file.
+	ImportMaxMB int `mapstructure:"IMPORT_MAX_MB"`
+
+	TrashRetentionDays    int `mapstructure:"TRASH_RETENTION_DAYS"`
+	IdempotencyTTLHours   int `mapstructure:"IDEMPOTENCY_TTL_HOURS"`
+	LyricsStatsTTLMinutes int `mapstructure:"LYRICS_STATS_TTL_MINUTES"`
+
+	// RateLimitStore is where request counters are kept: memory (per
+	// instance), postgres (shared by all instances) or off.
+	RateLimitStore string `mapstructure:"RATE_LIMIT_STORE"`
+	// Requests a minute per IP for anonymous clients and per API key.
+	RateLimitIPReadPerMinute  int `mapstructure:"RATE_LIMIT_IP_READ_PER_MINUTE"`
+	RateLimitIPWritePerMinute int `mapstructure:"RATE_LIMIT_IP_WRITE_PER_MINUTE"`
+	// RateLimitIPAuthPerMinute limits requests with an API key per IP
+	// before the key is checked.
+	RateLimitIPAuthPerMinute   int `mapstructure:"RATE_LIMIT_IP_AUTH_PER_MINUTE"`
+	RateLimitKeyReadPerMinute  int `mapstructure:"RATE_LIMIT_KEY_READ_PER_MINUTE"`
+	RateLimitKeyWritePerMinute int `mapstructure:"RATE_LIMIT_KEY_WRITE_PER_MINUTE"`
+	// RateLimitKeyDailyQuota is the default requests a day per API key; zero
+	// means no quota.
+	RateLimitKeyDailyQuota int `mapstructure:"RATE_LIMIT_KEY_DAILY_QUOTA"`
+	// RateLimitTrustProxy takes the client IP from the last X-Forwarded-For
+	// entry, as appended by a single reverse proxy.
+	RateLimitTrustProxy bool `mapstructure:"RATE_LIMIT_TRUST_PROXY"`
+
+	// LinkCheckIntervalHours is how often each song link is checked; zero
+	// disables the link checker.
+	LinkCheckIntervalHours int `mapstructure:"LINK_CHECK_INTERVAL_HOURS"`
+}
+
+// Redacted returns a copy of the config that is safe to log, with the admin
+// key and database password hidden.
+func (c Config) Redacted() Config {
+	const hidden = "[redacted]"
+	if c.AdminAPIKey != "" {
+		c.AdminAPIKey = hidden
+	}
+	if c.DBPassword != "" {
+		c.DBPassword = hidden
+	}
+	if u, err := url.Parse(c.DBConn); err == nil {
+		c.DBConn = u.Redacted()
+	}
+	return c
 }
 
+// LoadConfig reads .env; environment variables override its values.
 func LoadConfig(path string) (cfg *Config, err error) {
 	viper.AddConfigPath(path)
 	viper.SetConfigFile(".env")
+	viper.AutomaticEnv()
 
 	err = viper.ReadInConfig()
 	if err != nil {
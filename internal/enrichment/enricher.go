@@ -0,0 +1,196 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	FieldReleaseDate = "release_date"
+	FieldText        = "text"
+	FieldLink        = "link"
+)
+
+// Fields are the song fields an Enricher can fill, in the order they are
+// reported.
+var Fields = []string{FieldReleaseDate, FieldText, FieldLink}
+
+// ErrNotFound is returned by an Enricher that does not know the song.
+var ErrNotFound = errors.New("song not found")
+
+// Enricher looks up details of a song in one source. Fields it does not
+// know are left empty.
+type Enricher interface {
+	Name() string
+	Lookup(ctx context.Context, group, song string) (entities.Details, error)
+}
+
+// Result holds the merged details and, for every filled field, the source
+// it was taken from.
+type Result struct {
+	Details entities.Details
+	Sources []entities.FieldSource
+}
+
+// Pipeline merges details from several enrichers. Each field is taken from
+// the first enricher in priority order that has it, unless a rule prefers
+// a particular enricher for that field; the preferred enricher is asked
+// first and the rest are the fallback.
+type Pipeline struct {
+	enrichers []Enricher
+	prefer    map[string]string
+	logg      *logger.Logger
+}
+
+func NewPipeline(enrichers []Enricher, prefer map[string]string, logg *logger.Logger) *Pipeline {
+	for field, name := range prefer {
+		if !slices.ContainsFunc(enrichers, func(e Enricher) bool { return e.Name() == name }) {
+			logg.WithFields(logrus.Fields{
+				"field":    field,
+				"provider": name,
+			}).Warn("Enrichment rule prefers a provider that is not configured")
+		}
+	}
+	return &Pipeline{
+		enrichers: enrichers,
+		prefer:    prefer,
+		logg:      logg,
+	}
+}
+
+// ParseRules parses per-field rules such as "text=catalogue,link=api" into
+// a map of field to preferred enricher.
+func ParseRules(rules string) (map[string]string, error) {
+	prefer := map[string]string{}
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		field, name, ok := strings.Cut(rule, "=")
+		field, name = strings.TrimSpace(field), strings.TrimSpace(name)
+		if !ok || name == "" || !slices.Contains(Fields, field) {
+			return nil, fmt.Errorf("invalid enrichment rule %q, expected <field>=<provider> with field one of %s", rule, strings.Join(Fields, ", "))
+		}
+		prefer[field] = name
+	}
+	return prefer, nil
+}
+
+// Enrich looks the song up and fills the requested fields, or all fields
+// when none are given. Enrichers are asked at most once and only while a
+// field is still missing. It returns ErrNotFound when no enricher knows the
+// song, and the last provider error when an enricher failed and none but
+// the placeholder answered.
+func (p *Pipeline) Enrich(ctx context.Context, group, song string, fields ...string) (Result, error) {
+	if len(fields) == 0 {
+		fields = Fields
+	}
+
+	type lookup struct {
+		details entities.Details
+		found   bool
+	}
+	lookups := map[string]lookup{}
+	var lastErr error
+	answered := false
+	get := func(enricher Enricher) lookup {
+		if l, ok := lookups[enricher.Name()]; ok {
+			return l
+		}
+		details, err := enricher.Lookup(ctx, group, song)
+		l := lookup{details: details, found: err == nil}
+		if l.found && enricher.Name() != ProviderPlaceholder {
+			answered = true
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			lastErr = err
+			p.logg.WithError(err).WithFields(logrus.Fields{
+				"provider": enricher.Name(),
+				"group":    group,
+				"song":     song,
+			}).Warn("Enrichment provider failed")
+		}
+		lookups[enricher.Name()] = l
+		return l
+	}
+
+	var result Result
+	for _, field := range fields {
+		for _, enricher := range p.order(field) {
+			l := get(enricher)
+			if !l.found {
+				continue
+			}
+			if value := fieldValue(l.details, field); value != "" {
+				setField(&result.Details, field, value)
+				result.Sources = append(result.Sources, entities.FieldSource{
+					Field:      field,
+					Provider:   enricher.Name(),
+					EnrichedAt: time.Now(),
+				})
+				break
+			}
+		}
+	}
+
+	if lastErr != nil && !answered {
+		return Result{}, lastErr
+	}
+	if len(result.Sources) == 0 {
+		return Result{}, ErrNotFound
+	}
+	return result, nil
+}
+
+// order returns the enrichers to ask for field, the preferred one first.
+func (p *Pipeline) order(field string) []Enricher {
+	name, ok := p.prefer[field]
+	if !ok {
+		return p.enrichers
+	}
+	ordered := make([]Enricher, 0, len(p.enrichers))
+	for _, enricher := range p.enrichers {
+		if enricher.Name() == name {
+			ordered = append(ordered, enricher)
+		}
+	}
+	for _, enricher := range p.enrichers {
+		if enricher.Name() != name {
+			ordered = append(ordered, enricher)
+		}
+	}
+	return ordered
+}
+
+func fieldValue(details entities.Details, field string) string {
+	switch field {
+	case FieldReleaseDate:
+		return strings.TrimSpace(details.ReleaseDate)
+	case FieldText:
+		return strings.TrimSpace(details.Text)
+	case FieldLink:
+		return strings.TrimSpace(details.Link)
+	}
+	return ""
+}
+
+func setField(details *entities.Details, field, value string) {
+	switch field {
+	case FieldReleaseDate:
+		details.ReleaseDate = value
+	case FieldText:
+		details.Text = value
+	case FieldLink:
+		details.Link = value
+	}
+}
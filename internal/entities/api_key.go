@@ -0,0 +1,40 @@
+package entities
+
+import "time"
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	Role       string     `json:"role"`
+	DailyQuota *int       `json:"daily_quota,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	Role      string     `json:"role,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// DailyQuota overrides the default number of requests a day; 0 means
+	// no quota.
+	DailyQuota *int `json:"daily_quota,omitempty"`
+}
+
+type CreatedAPIKey struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
+}
+
+type RoleAssignment struct {
+	Role string `json:"role"`
+}
+
+// QuotaAssignment sets the daily quota of a key; null restores the default.
+type QuotaAssignment struct {
+	DailyQuota *int `json:"daily_quota"`
+}
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionMerge   = "merge"
+	AuditActionImport  = "import"
+
+	AuditEntitySong         = "song"
+	AuditEntityImportJob    = "import_job"
+	AuditEntitySyncedLyrics = "synced_lyrics"
+	AuditEntitySongLyrics   = "song_lyrics"
+	AuditEntitySongLink     = "song_link"
+)
+
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	ActorKeyID *int            `json:"actor_key_id,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	Diff       json.RawMessage `json:"diff,omitempty" swaggertype:"object"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type AuditFilters struct {
+	Actor      string
+	Action     string
+	EntityType string
+	EntityID   int
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+}
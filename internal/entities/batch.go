@@ -0,0 +1,35 @@
+package entities
+
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+type BatchRequest struct {
+	BestEffort bool             `json:"best_effort"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchOperation struct {
+	Op   string `json:"op"`
+	ID   int    `json:"id,omitempty"`
+	Song *Song  `json:"song,omitempty"`
+}
+
+type BatchResult struct {
+	Index      int    `json:"index"`
+	Op         string `json:"op"`
+	ID         int    `json:"id,omitempty"`
+	Status     int    `json:"status"`
+	Error      string `json:"error,omitempty"`
+	ExistingID int    `json:"existing_id,omitempty"`
+	Err        error  `json:"-"`
+	Skipped    bool   `json:"-"`
+}
+
+type BatchResponse struct {
+	BestEffort bool          `json:"best_effort"`
+	Committed  bool          `json:"committed"`
+	Results    []BatchResult `json:"results"`
+}
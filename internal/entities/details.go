@@ -1,7 +1,49 @@
 package entities
 
+import "time"
+
 type Details struct {
 	ReleaseDate string `json:"releaseDate"`
 	Text        string `json:"text"`
 	Link        string `json:"link"`
 }
+
+// FieldSource records which enrichment provider a field of a song was
+// taken from.
+type FieldSource struct {
+	Field      string    `json:"field"`
+	Provider   string    `json:"provider"`
+	EnrichedAt time.Time `json:"enriched_at"`
+}
+
+// SourceManual is the source of fields edited by hand.
+const SourceManual = "manual"
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusAccepted = "accepted"
+	ReviewStatusRejected = "rejected"
+)
+
+// EnrichmentReview is a value found upstream for a field that was edited by
+// hand. It is applied only once an editor accepts it.
+type EnrichmentReview struct {
+	ID            int        `json:"id"`
+	SongID        int        `json:"song_id"`
+	Field         string     `json:"field"`
+	CurrentValue  string     `json:"current_value"`
+	ProposedValue string     `json:"proposed_value"`
+	Provider      string     `json:"provider"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy    string     `json:"resolved_by,omitempty"`
+}
+
+// RefreshResult lists the fields a re-enrichment updated and the reviews
+// it opened.
+type RefreshResult struct {
+	SongID  int                `json:"song_id"`
+	Updated []string           `json:"updated"`
+	Reviews []EnrichmentReview `json:"reviews"`
+}
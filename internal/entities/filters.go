@@ -3,6 +3,7 @@ package entities
 type SongFilters struct {
 	GroupName string
 	SongName  string
+	Explicit  *bool
 }
 
 type Pagination struct {
@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a write request made with an
+// Idempotency-Key. StatusCode is zero while the original request is still
+// being processed.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	Header      map[string][]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,38 @@
+package entities
+
+import "time"
+
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+type ImportJob struct {
+	ID             int              `json:"id"`
+	Format         string           `json:"format"`
+	DryRun         bool             `json:"dry_run"`
+	Status         string           `json:"status"`
+	RowsProcessed  int              `json:"rows_processed"`
+	RowsInserted   int              `json:"rows_inserted"`
+	RowsFailed     int              `json:"rows_failed"`
+	BytesTotal     int64            `json:"bytes_total"`
+	BytesProcessed int64            `json:"bytes_processed"`
+	Errors         []ImportRowError `json:"errors"`
+	Error          string           `json:"error,omitempty"`
+	CreatedBy      string           `json:"created_by"`
+	CreatedAt      time.Time        `json:"created_at"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	FinishedAt     *time.Time       `json:"finished_at,omitempty"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportRow struct {
+	Row  int
+	Song Song
+}
@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+const (
+	LinkStatusUnchecked = "unchecked"
+	LinkStatusAlive     = "alive"
+	LinkStatusDead      = "dead"
+)
+
+// SongLink is an external link to a song. The primary link is the one
+// stored in Song.Link. Status is set by the link checker; Failures counts
+// failed checks in a row that did not prove the link dead outright.
+type SongLink struct {
+	ID         int        `json:"id"`
+	SongID     int        `json:"song_id"`
+	URL        string     `json:"url"`
+	Provider   string     `json:"provider"`
+	Primary    bool       `json:"primary"`
+	Status     string     `json:"status"`
+	HTTPStatus *int       `json:"http_status,omitempty"`
+	Failures   int        `json:"failures"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type SongLinkRequest struct {
+	URL     string `json:"url"`
+	Primary bool   `json:"primary"`
+}
@@ -0,0 +1,97 @@
+package entities
+
+import "time"
+
+// SyncedLyrics are time-synchronized lyrics of a song, as read from an LRC
+// file. Times are in milliseconds from the start of the track.
+type SyncedLyrics struct {
+	SongID    int               `json:"song_id"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Lines     []LyricLine       `json:"lines"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// LyricLine is shown from StartMS until EndMS. EndMS is unknown for the last
+// line unless the track length is known.
+type LyricLine struct {
+	StartMS int64       `json:"start_ms"`
+	EndMS   *int64      `json:"end_ms,omitempty"`
+	Text    string      `json:"text"`
+	Words   []LyricWord `json:"words,omitempty"`
+}
+
+// LyricWord carries word-level timing from enhanced LRC.
+type LyricWord struct {
+	StartMS int64  `json:"start_ms"`
+	Text    string `json:"text"`
+}
+
+type ActiveLyricLine struct {
+	Index int `json:"index"`
+	LyricLine
+}
+
+// SongLyrics is the text of a song in one language. The original version
+// mirrors Song.Text; the others are translations. Sections is the parsed
+// text, nil until it has been parsed.
+type SongLyrics struct {
+	SongID    int            `json:"song_id"`
+	Language  string         `json:"language"`
+	Original  bool           `json:"original"`
+	Text      string         `json:"text"`
+	Sections  []LyricSection `json:"-"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type LyricsRequest struct {
+	Text string `json:"text"`
+}
+
+type OriginalLanguageRequest struct {
+	Language string `json:"language"`
+}
+
+// TextOptions selects which version of the lyrics GetSongText returns. With
+// Bilingual, sections of the original are paired with those of Language.
+// Types keeps only sections of the given types, ExpandRepeats writes out
+// repeated sections in full, and Mask hides explicit words.
+type TextOptions struct {
+	Language      string
+	Bilingual     bool
+	Types         []string
+	ExpandRepeats bool
+	Mask          bool
+	Pagination
+}
+
+type SongText struct {
+	SongID           int            `json:"song_id"`
+	Language         string         `json:"language"`
+	OriginalLanguage string         `json:"original_language,omitempty"`
+	Total            int            `json:"total"`
+	Sections         []LyricSection `json:"sections"`
+}
+
+const (
+	SectionIntro     = "intro"
+	SectionVerse     = "verse"
+	SectionPreChorus = "pre-chorus"
+	SectionChorus    = "chorus"
+	SectionBridge    = "bridge"
+	SectionOutro     = "outro"
+	SectionOther     = "other"
+)
+
+// LyricSection is one section of the lyrics. Repeat is how many times in a
+// row it is sung, and RepeatOf is the number of the earlier section it
+// repeats. In bilingual mode Translation holds the same section in the
+// requested language.
+type LyricSection struct {
+	Number      int      `json:"number"`
+	Type        string   `json:"type"`
+	Label       string   `json:"label,omitempty"`
+	Repeat      int      `json:"repeat"`
+	RepeatOf    int      `json:"repeat_of,omitempty"`
+	Lines       []string `json:"lines"`
+	Translation []string `json:"translation,omitempty"`
+}
@@ -0,0 +1,25 @@
+package entities
+
+type MergeRequest struct {
+	SurvivorID int            `json:"survivor_id"`
+	SongIDs    []int          `json:"song_ids"`
+	Fields     map[string]int `json:"fields,omitempty"`
+}
+
+type MergePreview struct {
+	Survivor            Song           `json:"survivor"`
+	MergedIDs           []int          `json:"merged_ids"`
+	FieldSources        map[string]int `json:"field_sources"`
+	ReassignedRevisions int            `json:"reassigned_revisions"`
+	MergeMoves
+}
+
+// MergeMoves lists what a merge moved from the merged songs to the
+// survivor. Translations are moved for languages the survivor lacks, and
+// synced lyrics only if it has none; both are taken from the merged song
+// with the lowest ID.
+type MergeMoves struct {
+	MovedTranslations []string `json:"moved_translations,omitempty"`
+	// SyncedLyricsFrom is the song whose synced lyrics were moved.
+	SyncedLyricsFrom *int `json:"synced_lyrics_from,omitempty"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+// LimiterStats describes the limiter in front of the external music API.
+type LimiterStats struct {
+	RPS    float64              `json:"rps"`
+	Burst  int                  `json:"burst"`
+	Tokens float64              `json:"tokens"`
+	Lanes  map[string]LaneStats `json:"lanes"`
+}
+
+// LaneStats counts the requests of one priority lane since start. Requests
+// are the granted ones, Delayed of them had to queue for a token; WaitAvgMs
+// and WaitMaxMs are the time spent queued over all granted requests.
+// Cancelled requests gave up while queued and are not counted otherwise.
+type LaneStats struct {
+	Requests  int64   `json:"requests"`
+	Delayed   int64   `json:"delayed"`
+	Cancelled int64   `json:"cancelled"`
+	Queued    int     `json:"queued"`
+	WaitAvgMs float64 `json:"wait_avg_ms"`
+	WaitMaxMs float64 `json:"wait_max_ms"`
+}
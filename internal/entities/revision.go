@@ -0,0 +1,34 @@
+package entities
+
+import "time"
+
+type SongRevision struct {
+	SongID      int       `json:"song_id"`
+	Revision    int       `json:"revision"`
+	GroupName   string    `json:"group"`
+	SongName    string    `json:"song"`
+	ReleaseDate string    `json:"release_date"`
+	Text        string    `json:"text"`
+	Link        string    `json:"link"`
+	Actor       string    `json:"actor"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type LineChange struct {
+	Op   string `json:"op"`
+	Line string `json:"line"`
+}
+
+type RevisionDiff struct {
+	SongID int           `json:"song_id"`
+	From   int           `json:"from"`
+	To     int           `json:"to"`
+	Fields []FieldChange `json:"fields"`
+	Text   []LineChange  `json:"text,omitempty"`
+}
@@ -1,10 +1,30 @@
 package entities
 
+import "time"
+
 type Song struct {
-	ID          int    `json:"id"`
-	GroupName   string `json:"group"`
-	SongName    string `json:"song"`
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+	ID                   int        `json:"id"`
+	GroupName            string     `json:"group"`
+	SongName             string     `json:"song"`
+	ReleaseDate          string     `json:"release_date"`
+	ReleaseDatePrecision string     `json:"release_date_precision,omitempty"`
+	Text                 string     `json:"text"`
+	Link                 string     `json:"link"`
+	AllowDuplicate       bool       `json:"allow_duplicate,omitempty"`
+	Explicit             bool       `json:"explicit"`
+	ExplicitManual       bool       `json:"explicit_manual,omitempty"`
+	EnrichedAt           *time.Time `json:"enriched_at,omitempty"`
+	DeletedAt            *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ExplicitRequest sets the explicit flag by hand. A null Explicit hands the
+// flag back to automatic detection.
+type ExplicitRequest struct {
+	Explicit *bool `json:"explicit"`
+}
+
+type DuplicateReport struct {
+	SongID      int       `json:"song_id"`
+	DuplicateOf int       `json:"duplicate_of"`
+	DetectedAt  time.Time `json:"detected_at"`
 }
@@ -0,0 +1,44 @@
+package entities
+
+import "time"
+
+// LyricsStats describes the lyrics of one song. Counts are of the text as
+// written: section labels are not lyrics, and repeats are not written out.
+type LyricsStats struct {
+	SongID             int            `json:"song_id"`
+	Language           string         `json:"language"`
+	Words              int            `json:"words"`
+	UniqueWords        int            `json:"unique_words"`
+	UniqueWordRatio    float64        `json:"unique_word_ratio"`
+	Lines              int            `json:"lines"`
+	Verses             int            `json:"verses"`
+	ReadingTimeSeconds int            `json:"reading_time_seconds"`
+	TopWords           []WordCount    `json:"top_words"`
+	RepeatedLines      []RepeatedLine `json:"repeated_lines"`
+	ComputedAt         time.Time      `json:"computed_at"`
+}
+
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type RepeatedLine struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// CatalogueLyricsStats sums LyricsStats over all songs that are not in the
+// trash. Averages are over songs with lyrics.
+type CatalogueLyricsStats struct {
+	Songs                  int         `json:"songs"`
+	SongsWithLyrics        int         `json:"songs_with_lyrics"`
+	Words                  int         `json:"words"`
+	Lines                  int         `json:"lines"`
+	Verses                 int         `json:"verses"`
+	AverageWords           float64     `json:"average_words"`
+	AverageUniqueWordRatio float64     `json:"average_unique_word_ratio"`
+	ReadingTimeSeconds     int         `json:"reading_time_seconds"`
+	TopWords               []WordCount `json:"top_words"`
+	ComputedAt             time.Time   `json:"computed_at"`
+}
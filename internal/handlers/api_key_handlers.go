@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+type APIKeyHandler struct {
+	service services.APIKeyServiceInterface
+	logg    *logger.Logger
+}
+
+func NewAPIKeyHandler(service services.APIKeyServiceInterface, logg *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Создать API-ключ
+// @Description Создаёт ключ для сервисных клиентов. Значение ключа возвращается только один раз
+// @Tags API-ключи
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param key body entities.APIKeyRequest true "Имя, права (read, write, admin), роль и срок действия"
+// @Success 201 {object} entities.CreatedAPIKey
+// @Failure 400 {string} string "Неверные входные данные"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling CreateAPIKey request")
+
+	var req entities.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Invalid request payload")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.service.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to create API key")
+		writeError(w, err, "Failed to create API key")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// @Summary Получить список API-ключей
+// @Description Возвращает все ключи без их значений, включая время последнего использования
+// @Tags API-ключи
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} entities.APIKey
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetAPIKeys request")
+
+	keys, err := h.service.GetAPIKeys(r.Context())
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch API keys")
+		writeError(w, err, "Failed to fetch API keys")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+// @Summary Отозвать API-ключ
+// @Description Отзывает ключ по ID, после чего запросы с ним отклоняются
+// @Tags API-ключи
+// @Security ApiKeyAuth
+// @Param id path int true "ID ключа"
+// @Success 204 {string} string "Ключ отозван"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Ключ не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling RevokeAPIKey request")
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api-keys/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logg.WithField("id", idStr).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), id); err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to revoke API key")
+		writeError(w, err, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Назначить роль API-ключу
+// @Description Назначает ключу роль viewer, editor или admin
+// @Tags API-ключи
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID ключа"
+// @Param role body entities.RoleAssignment true "Роль"
+// @Success 200 {object} entities.APIKey
+// @Failure 400 {string} string "Неверные входные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Ключ не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api-keys/{id}/role [put]
+func (h *APIKeyHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling AssignRole request")
+
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logg.WithField("id", idStr).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req entities.RoleAssignment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Invalid request payload")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.service.AssignRole(r.Context(), id, req.Role)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to assign role")
+		writeError(w, err, "Failed to assign role")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+// @Summary Задать дневную квоту API-ключу
+// @Description Задаёт число запросов в сутки (UTC) для ключа; 0 снимает квоту, null возвращает квоту по умолчанию
+// @Tags API-ключи
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID ключа"
+// @Param quota body entities.QuotaAssignment true "Квота"
+// @Success 200 {object} entities.APIKey
+// @Failure 400 {string} string "Неверные входные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Ключ не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /api-keys/{id}/quota [put]
+func (h *APIKeyHandler) SetDailyQuota(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling SetDailyQuota request")
+
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logg.WithField("id", idStr).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req entities.QuotaAssignment
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Invalid request payload")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.service.SetDailyQuota(r.Context(), id, req.DailyQuota)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to set daily quota")
+		writeError(w, err, "Failed to set daily quota")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(key)
+}
+
+// @Summary Получить роли
+// @Description Возвращает роли и входящие в них разрешения
+// @Tags API-ключи
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Router /roles [get]
+func (h *APIKeyHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetRoles request")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auth.RolePermissions())
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+type AuditHandler struct {
+	service services.AuditServiceInterface
+	logg    *logger.Logger
+}
+
+func NewAuditHandler(service services.AuditServiceInterface, logg *logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Получить журнал изменений
+// @Description Возвращает события аудита каталога (новые первыми) с фильтрацией и пагинацией
+// @Tags Аудит
+// @Produce json
+// @Security ApiKeyAuth
+// @Param actor query string false "Имя ключа, выполнившего изменение"
+// @Param action query string false "Действие (create, update, delete)"
+// @Param entity_type query string false "Тип сущности"
+// @Param entity_id query int false "ID сущности"
+// @Param request_id query string false "ID запроса"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода (RFC 3339)"
+// @Param page query int false "Номер страницы"
+// @Param per_page query int false "Количество элементов на странице"
+// @Success 200 {array} entities.AuditEvent
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /audit [get]
+func (h *AuditHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetEvents request")
+
+	q := r.URL.Query()
+	filters := entities.AuditFilters{
+		Actor:      q.Get("actor"),
+		Action:     q.Get("action"),
+		EntityType: q.Get("entity_type"),
+		EntityID:   toInt(q.Get("entity_id"), 0),
+		RequestID:  q.Get("request_id"),
+	}
+	for param, target := range map[string]**time.Time{"from": &filters.From, "to": &filters.To} {
+		value := q.Get(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.logg.WithError(err).WithField(param, value).Error("Invalid time filter")
+			http.Error(w, "Invalid "+param+": expected RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		*target = &t
+	}
+	pagination := entities.Pagination{
+		Page:    toInt(q.Get("page"), 1),
+		PerPage: toInt(q.Get("per_page"), 50),
+	}
+
+	events, err := h.service.GetEvents(r.Context(), filters, pagination)
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch audit events")
+		writeError(w, err, "Failed to fetch audit events")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
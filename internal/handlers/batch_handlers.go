@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+// @Summary Пакетные операции с песнями
+// @Description Выполняет список операций create, update и delete. По умолчанию все операции выполняются в одной транзакции и применяются, только если успешны все; с best_effort каждая операция выполняется независимо
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param batch body entities.BatchRequest true "Список операций"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Success 200 {object} entities.BatchResponse
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/batch [post]
+func (h *SongHandler) ExecuteBatch(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling ExecuteBatch request")
+
+	var req entities.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Invalid request body")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.service.ExecuteBatch(r.Context(), req)
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to execute batch")
+		writeError(w, err, "Failed to execute batch")
+		return
+	}
+
+	for i := range resp.Results {
+		setBatchStatus(&resp.Results[i])
+	}
+
+	h.logg.WithField("committed", resp.Committed).Info("Batch executed")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// setBatchStatus reports each operation with the status the equivalent
+// single request would have returned. Operations of an atomic batch that
+// were not applied because another operation failed get 424 Failed
+// Dependency.
+func setBatchStatus(result *entities.BatchResult) {
+	if result.Skipped {
+		result.Status = http.StatusFailedDependency
+		result.Error = "not applied because another operation in the batch failed"
+		return
+	}
+	if result.Err != nil {
+		result.Status = errorStatus(result.Err)
+		result.Error = "operation failed"
+		if result.Status != http.StatusInternalServerError {
+			result.Error = result.Err.Error()
+		}
+		var dupErr *services.DuplicateError
+		if errors.As(result.Err, &dupErr) {
+			result.ExistingID = dupErr.ExistingID
+		}
+		return
+	}
+
+	switch result.Op {
+	case entities.BatchOpCreate:
+		result.Status = http.StatusCreated
+	case entities.BatchOpDelete:
+		result.Status = http.StatusNoContent
+	default:
+		result.Status = http.StatusOK
+	}
+}
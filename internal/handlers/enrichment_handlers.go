@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+type EnrichmentHandler struct {
+	service services.EnrichmentServiceInterface
+	logg    *logger.Logger
+}
+
+func NewEnrichmentHandler(service services.EnrichmentServiceInterface, logg *logger.Logger) *EnrichmentHandler {
+	return &EnrichmentHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Получить источники данных песни
+// @Description Возвращает, из какого источника (api, catalogue, fixtures, placeholder) взято каждое поле, заполненное при добавлении песни
+// @Tags Обогащение
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {array} entities.FieldSource
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/sources [get]
+func (h *EnrichmentHandler) GetFieldSources(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetFieldSources request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	sources, err := h.service.GetFieldSources(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch field sources")
+		writeError(w, err, "Failed to fetch field sources")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sources)
+}
+
+// @Summary Обновить данные песни из источников
+// @Description Заново запрашивает поля песни у источников обогащения. Поля, взятые из источника, обновляются, если данные изменились; для полей, изменённых вручную, новое значение попадает в очередь на проверку
+// @Tags Обогащение
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Success 200 {object} entities.RefreshResult
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/refresh [post]
+func (h *EnrichmentHandler) RefreshSong(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling RefreshSong request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.RefreshSong(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to refresh song")
+		writeError(w, err, "Failed to refresh song")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// @Summary Очередь проверки обогащения
+// @Description Возвращает значения из источников, расходящиеся с полями, изменёнными вручную. Сначала самые старые
+// @Tags Обогащение
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Статус: pending, accepted или rejected (по умолчанию pending)"
+// @Param page query int false "Номер страницы"
+// @Param per_page query int false "Количество записей на странице"
+// @Success 200 {array} entities.EnrichmentReview
+// @Failure 400 {string} string "Неверный статус"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /enrichment/reviews [get]
+func (h *EnrichmentHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetReviews request")
+
+	q := r.URL.Query()
+	status := q.Get("status")
+	if !q.Has("status") {
+		status = entities.ReviewStatusPending
+	}
+	pagination := entities.Pagination{
+		Page:    toInt(q.Get("page"), 1),
+		PerPage: toInt(q.Get("per_page"), 50),
+	}
+
+	reviews, err := h.service.GetReviews(r.Context(), status, pagination)
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch enrichment reviews")
+		writeError(w, err, "Failed to fetch enrichment reviews")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviews)
+}
+
+// @Summary Принять значение из источника
+// @Description Записывает предложенное значение в песню; поле снова считается взятым из источника
+// @Tags Обогащение
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID записи в очереди"
+// @Success 200 {object} entities.EnrichmentReview
+// @Failure 400 {string} string "Запись уже рассмотрена"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Запись не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /enrichment/reviews/{id}/accept [post]
+func (h *EnrichmentHandler) AcceptReview(w http.ResponseWriter, r *http.Request) {
+	h.resolveReview(w, r, h.service.AcceptReview)
+}
+
+// @Summary Отклонить значение из источника
+// @Description Оставляет значение, изменённое вручную. То же значение из источника больше не предлагается
+// @Tags Обогащение
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID записи в очереди"
+// @Success 200 {object} entities.EnrichmentReview
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Запись не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /enrichment/reviews/{id}/reject [post]
+func (h *EnrichmentHandler) RejectReview(w http.ResponseWriter, r *http.Request) {
+	h.resolveReview(w, r, h.service.RejectReview)
+}
+
+func (h *EnrichmentHandler) resolveReview(w http.ResponseWriter, r *http.Request, resolve func(ctx context.Context, id int) (entities.EnrichmentReview, error)) {
+	h.logg.WithField("method", r.Method).Debug("Handling enrichment review request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	review, err := resolve(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to resolve enrichment review")
+		writeError(w, err, "Failed to resolve enrichment review")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// @Summary Ограничение запросов к внешнему API
+// @Description Возвращает настройки ограничителя запросов к /info и для каждой очереди (interactive, batch) число запросов, сколько из них ждали в очереди и среднее и максимальное время ожидания
+// @Tags Обогащение
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} entities.LimiterStats
+// @Failure 403 {string} string "Недостаточно прав"
+// @Router /enrichment/limiter [get]
+func (h *EnrichmentHandler) GetLimiterStats(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetLimiterStats request")
+
+	stats, err := h.service.GetLimiterStats(r.Context())
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch limiter stats")
+		writeError(w, err, "Failed to fetch limiter stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+// writeError maps service errors onto HTTP statuses. Errors without a known
+// mapping are reported as 500 with the fallback message, so internal details
+// are not leaked to clients.
+func writeError(w http.ResponseWriter, err error, fallback string) {
+	var permErr *auth.PermissionError
+	var dupErr *services.DuplicateError
+	switch status := errorStatus(err); {
+	case errors.As(err, &dupErr):
+		writeConflict(w, dupErr)
+	case errors.As(err, &permErr):
+		http.Error(w, permErr.Error(), status)
+	case status == http.StatusInternalServerError:
+		http.Error(w, fallback, status)
+	default:
+		http.Error(w, err.Error(), status)
+	}
+}
+
+func writeConflict(w http.ResponseWriter, err *services.DuplicateError) {
+	location := "/songs/" + strconv.Itoa(err.ExistingID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       err.Error(),
+		"existing_id": err.ExistingID,
+		"location":    location,
+	})
+}
+
+// errorStatus returns the HTTP status writeError would use for err.
+func errorStatus(err error) int {
+	var permErr *auth.PermissionError
+	var dupErr *services.DuplicateError
+	switch {
+	case errors.As(err, &dupErr):
+		return http.StatusConflict
+	case errors.As(err, &permErr):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrUnauthorized):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
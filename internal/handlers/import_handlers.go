@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+	"github.com/senyabanana/library-service/internal/songio"
+)
+
+type ImportHandler struct {
+	service services.ImportServiceInterface
+	// maxBytes caps the size of an uploaded file.
+	maxBytes int64
+	logg     *logger.Logger
+}
+
+func NewImportHandler(service services.ImportServiceInterface, maxBytes int64, logg *logger.Logger) *ImportHandler {
+	return &ImportHandler{
+		service:  service,
+		maxBytes: maxBytes,
+		logg:     logg,
+	}
+}
+
+// @Summary Массовый импорт песен
+// @Description Принимает файл CSV или NDJSON и запускает фоновую задачу импорта. Формат берётся из параметра format или заголовка Content-Type
+// @Tags Импорт
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Формат файла (csv, ndjson)"
+// @Param dry_run query bool false "Только проверить файл, ничего не сохраняя"
+// @Success 202 {object} entities.ImportJob
+// @Failure 400 {string} string "Неверный формат"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 413 {string} string "Файл слишком большой"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /songs/import [post]
+func (h *ImportHandler) StartImport(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling StartImport request")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = formatFromContentType(r.Header.Get("Content-Type"))
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	job, err := h.service.StartImport(r.Context(), format, dryRun, http.MaxBytesReader(w, r.Body, h.maxBytes))
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		h.logg.WithField("limit", maxErr.Limit).Error("Import file is too large")
+		http.Error(w, fmt.Sprintf("Import file is larger than %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to start import")
+		writeError(w, err, "Failed to start import")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/imports/%d", job.ID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary Получить статус импорта
+// @Description Возвращает прогресс задачи импорта и ошибки по строкам
+// @Tags Импорт
+// @Produce json
+// @Param id path int true "ID задачи импорта"
+// @Success 200 {object} entities.ImportJob
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Задача не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /imports/{id} [get]
+func (h *ImportHandler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetImportJob request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := h.service.GetImportJob(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch import job")
+		writeError(w, err, "Failed to fetch import job")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+func formatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return songio.FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return songio.FormatNDJSON
+	}
+	return ""
+}
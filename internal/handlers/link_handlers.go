@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+type LinkHandler struct {
+	service services.LinkServiceInterface
+	logg    *logger.Logger
+}
+
+func NewLinkHandler(service services.LinkServiceInterface, logg *logger.Logger) *LinkHandler {
+	return &LinkHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Получить ссылки на песню
+// @Description Возвращает внешние ссылки на песню (YouTube, Spotify, Apple Music, Bandcamp и др.) с провайдером и результатом последней проверки. Основная ссылка идёт первой
+// @Tags Ссылки
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {array} entities.SongLink
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/links [get]
+func (h *LinkHandler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetLinks request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	songLinks, err := h.service.GetLinks(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch song links")
+		writeError(w, err, "Failed to fetch song links")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(songLinks)
+}
+
+// @Summary Добавить ссылку на песню
+// @Description Добавляет внешнюю ссылку на песню. Ссылка приводится к каноническому виду (без трекинговых параметров вроде utm_*, si, fbclid), провайдер определяется по домену. С primary=true ссылка становится основной и записывается в поле link песни
+// @Tags Ссылки
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param link body entities.SongLinkRequest true "Ссылка"
+// @Success 201 {object} entities.SongLink
+// @Failure 400 {string} string "Неверная ссылка или ссылка уже добавлена"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/links [post]
+func (h *LinkHandler) AddLink(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling AddLink request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req entities.SongLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Failed to decode request body")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	added, err := h.service.AddLink(r.Context(), id, req)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to add song link")
+		writeError(w, err, "Failed to add song link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(added)
+}
+
+// @Summary Удалить ссылку на песню
+// @Description Удаляет внешнюю ссылку. Если она была основной, основной становится самая старая из оставшихся
+// @Tags Ссылки
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param linkId path int true "ID ссылки"
+// @Success 204 {string} string "Ссылка удалена"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Ссылка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/links/{linkId} [delete]
+func (h *LinkHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling DeleteLink request")
+
+	id, ok := pathInt(r, "id")
+	linkID, linkOK := pathInt(r, "linkId")
+	if !ok || !linkOK {
+		h.logg.WithField("id", r.PathValue("id")).WithField("link_id", r.PathValue("linkId")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteLink(r.Context(), id, linkID); err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to delete song link")
+		writeError(w, err, "Failed to delete song link")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Сделать ссылку основной
+// @Description Делает ссылку основной: она записывается в поле link песни
+// @Tags Ссылки
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param linkId path int true "ID ссылки"
+// @Success 200 {object} entities.SongLink
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Ссылка не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/links/{linkId}/primary [put]
+func (h *LinkHandler) SetPrimaryLink(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling SetPrimaryLink request")
+
+	id, ok := pathInt(r, "id")
+	linkID, linkOK := pathInt(r, "linkId")
+	if !ok || !linkOK {
+		h.logg.WithField("id", r.PathValue("id")).WithField("link_id", r.PathValue("linkId")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.service.SetPrimaryLink(r.Context(), id, linkID)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to set primary song link")
+		writeError(w, err, "Failed to set primary song link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(link)
+}
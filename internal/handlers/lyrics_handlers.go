@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/lyrics"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+const maxLRCSize = 1 << 20
+
+type LyricsHandler struct {
+	service services.LyricsServiceInterface
+	logg    *logger.Logger
+}
+
+func NewLyricsHandler(service services.LyricsServiceInterface, logg *logger.Logger) *LyricsHandler {
+	return &LyricsHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Загрузить синхронизированный текст
+// @Description Сохраняет текст песни с временными метками из файла LRC или расширенного LRC (с метками слов). Заменяет ранее загруженный
+// @Tags Текст
+// @Accept plain
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param lrc body string true "Содержимое файла LRC"
+// @Success 200 {object} entities.SyncedLyrics
+// @Failure 400 {string} string "Неверный файл LRC"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/lrc [put]
+func (h *LyricsHandler) ImportLRC(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling ImportLRC request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLRCSize))
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to read LRC body")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	synced, err := h.service.ImportLRC(r.Context(), id, string(data))
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to import LRC")
+		writeError(w, err, "Failed to import LRC")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(synced)
+}
+
+// @Summary Выгрузить синхронизированный текст
+// @Description Возвращает текст песни в формате LRC. С enhanced=true добавляются метки слов, если они известны
+// @Tags Текст
+// @Produce plain
+// @Param id path int true "ID песни"
+// @Param enhanced query bool false "Расширенный LRC с метками слов"
+// @Success 200 {string} string "Файл LRC"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Синхронизированный текст не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/lrc [get]
+func (h *LyricsHandler) ExportLRC(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling ExportLRC request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	lrc, err := h.service.ExportLRC(r.Context(), id, r.URL.Query().Get("enhanced") == "true")
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to export LRC")
+		writeError(w, err, "Failed to export LRC")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, lrc)
+}
+
+// @Summary Удалить синхронизированный текст
+// @Description Удаляет временные метки текста песни. Сам текст песни не меняется
+// @Tags Текст
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Success 204 {string} string "Синхронизированный текст удалён"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Синхронизированный текст не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/lrc [delete]
+func (h *LyricsHandler) DeleteSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling DeleteSyncedLyrics request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteSyncedLyrics(r.Context(), id); err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to delete synced lyrics")
+		writeError(w, err, "Failed to delete synced lyrics")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Получить строки текста с временем
+// @Description Возвращает строки синхронизированного текста с временем начала и окончания в миллисекундах
+// @Tags Текст
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {object} entities.SyncedLyrics
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Синхронизированный текст не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/lines [get]
+func (h *LyricsHandler) GetSyncedLyrics(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetSyncedLyrics request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	synced, err := h.service.GetSyncedLyrics(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch synced lyrics")
+		writeError(w, err, "Failed to fetch synced lyrics")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(synced)
+}
+
+// @Summary Строка текста в момент воспроизведения
+// @Description Возвращает строку, которая звучит в указанный момент. Время задаётся в секундах (83.5) или как мм:сс.xx (1:23.50)
+// @Tags Текст
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param t query string true "Позиция воспроизведения"
+// @Success 200 {object} entities.ActiveLyricLine
+// @Failure 400 {string} string "Неверная позиция"
+// @Failure 404 {string} string "В этот момент строка не звучит"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/text/at [get]
+func (h *LyricsHandler) LineAt(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling LineAt request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	position, err := lyrics.ParsePosition(r.URL.Query().Get("t"))
+	if err != nil {
+		h.logg.WithError(err).Error("Invalid playback position")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.service.LineAt(r.Context(), id, position)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to find active line")
+		writeError(w, err, "Failed to find active line")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(line)
+}
+
+// @Summary Получить версии текста
+// @Description Возвращает оригинальный текст песни и все его переводы. Оригинал идёт первым
+// @Tags Текст
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {array} entities.SongLyrics
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics [get]
+func (h *LyricsHandler) GetLyricsVersions(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetLyricsVersions request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	versions, err := h.service.GetLyricsVersions(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch lyrics versions")
+		writeError(w, err, "Failed to fetch lyrics versions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// @Summary Сохранить перевод текста
+// @Description Создаёт или заменяет перевод текста песни на указанный язык (тег BCP 47, например en или pt-BR). Оригинал меняется через PUT /songs/{id}
+// @Tags Текст
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param lang path string true "Язык перевода"
+// @Param lyrics body entities.LyricsRequest true "Текст перевода"
+// @Success 200 {object} entities.SongLyrics
+// @Failure 400 {string} string "Неверный язык или пустой текст"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/{lang} [put]
+func (h *LyricsHandler) SaveTranslation(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling SaveTranslation request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req entities.LyricsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Failed to decode request body")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.service.SaveTranslation(r.Context(), id, r.PathValue("lang"), req.Text)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to save translation")
+		writeError(w, err, "Failed to save translation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// @Summary Удалить перевод текста
+// @Description Удаляет перевод текста песни. Оригинал удалить нельзя
+// @Tags Текст
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param lang path string true "Язык перевода"
+// @Success 204 {string} string "Перевод удалён"
+// @Failure 400 {string} string "Неверный язык"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Перевод не найден"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/{lang} [delete]
+func (h *LyricsHandler) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling DeleteTranslation request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteTranslation(r.Context(), id, r.PathValue("lang")); err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to delete translation")
+		writeError(w, err, "Failed to delete translation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Указать язык оригинала
+// @Description Задаёт язык оригинального текста песни. Язык не может совпадать с языком существующего перевода
+// @Tags Текст
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param language body entities.OriginalLanguageRequest true "Язык оригинала"
+// @Success 200 {object} entities.SongLyrics
+// @Failure 400 {string} string "Неверный язык"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/lyrics/original [put]
+func (h *LyricsHandler) SetOriginalLanguage(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling SetOriginalLanguage request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req entities.OriginalLanguageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Failed to decode request body")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	original, err := h.service.SetOriginalLanguage(r.Context(), id, req.Language)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to set original language")
+		writeError(w, err, "Failed to set original language")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(original)
+}
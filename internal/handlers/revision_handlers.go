@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+type RevisionHandler struct {
+	service services.RevisionServiceInterface
+	logg    *logger.Logger
+}
+
+func NewRevisionHandler(service services.RevisionServiceInterface, logg *logger.Logger) *RevisionHandler {
+	return &RevisionHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Получить историю изменений песни
+// @Description Возвращает ревизии песни, начиная с последней
+// @Tags Ревизии
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {array} entities.SongRevision
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/revisions [get]
+func (h *RevisionHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetRevisions request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := h.service.GetRevisions(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch revisions")
+		writeError(w, err, "Failed to fetch revisions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// @Summary Получить ревизию песни
+// @Description Возвращает состояние песни в указанной ревизии
+// @Tags Ревизии
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} entities.SongRevision
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Ревизия не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/revisions/{rev} [get]
+func (h *RevisionHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetRevision request")
+
+	id, ok := pathInt(r, "id")
+	rev, revOK := pathInt(r, "rev")
+	if !ok || !revOK {
+		h.logg.WithField("id", r.PathValue("id")).WithField("rev", r.PathValue("rev")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	revision, err := h.service.GetRevision(r.Context(), id, rev)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch revision")
+		writeError(w, err, "Failed to fetch revision")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revision)
+}
+
+// @Summary Сравнить ревизии песни
+// @Description Возвращает изменённые поля и построчный diff текста между двумя ревизиями. По умолчанию сравнивается последняя ревизия с предыдущей
+// @Tags Ревизии
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param from query int false "Исходная ревизия"
+// @Param to query int false "Итоговая ревизия"
+// @Success 200 {object} entities.RevisionDiff
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 404 {string} string "Ревизия не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/revisions/diff [get]
+func (h *RevisionHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling DiffRevisions request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	from := toInt(r.URL.Query().Get("from"), 0)
+	to := toInt(r.URL.Query().Get("to"), 0)
+
+	diff, err := h.service.DiffRevisions(r.Context(), id, from, to)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to diff revisions")
+		writeError(w, err, "Failed to diff revisions")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// @Summary Восстановить ревизию песни
+// @Description Возвращает песню к состоянию указанной ревизии. Восстановление сохраняется как новая ревизия
+// @Tags Ревизии
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param rev path int true "Номер ревизии"
+// @Success 200 {object} entities.Song
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Ревизия не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/revisions/{rev}/restore [post]
+func (h *RevisionHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling RestoreRevision request")
+
+	id, ok := pathInt(r, "id")
+	rev, revOK := pathInt(r, "rev")
+	if !ok || !revOK {
+		h.logg.WithField("id", r.PathValue("id")).WithField("rev", r.PathValue("rev")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	song, err := h.service.RestoreRevision(r.Context(), id, rev)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to restore revision")
+		writeError(w, err, "Failed to restore revision")
+		return
+	}
+
+	h.logg.WithField("id", id).Info("Revision restored successfully")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(song)
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +11,7 @@ import (
 	"github.com/senyabanana/library-service/internal/entities"
 	"github.com/senyabanana/library-service/internal/logger"
 	"github.com/senyabanana/library-service/internal/services"
+	"github.com/senyabanana/library-service/internal/songio"
 
 	"github.com/sirupsen/logrus"
 )
@@ -26,13 +29,19 @@ func NewSongHandler(service services.SongServiceInterface, logg *logger.Logger)
 }
 
 // @Summary Добавить новую песню
-// @Description Добавляет новую песню в библиотеку и обогащает её данными из внешнего API
+// @Description Добавляет новую песню в библиотеку. Не указанные дата выхода, текст и ссылка заполняются из источников обогащения в порядке приоритета (ENRICHMENT_PROVIDERS); если ни один источник не знает песню, возвращается 400. Песня с тем же исполнителем и названием (без учёта регистра, пробелов, диакритики и артикля "The") отклоняется, если не указан allow_duplicate
 // @Tags Песни
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param song body entities.Song true "Данные о песне"
-// @Success 201 {string} string "Песня успешно добавлена"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Success 201 {object} entities.Song
+// @Header 201 {string} Location "Адрес созданной песни"
 // @Failure 400 {string} string "Неверные входные данные"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 409 {object} map[string]interface{} "Песня уже существует"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /songs [post]
 func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
@@ -45,18 +54,112 @@ func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.AddSong(r.Context(), song); err != nil {
+	created, err := h.service.AddSong(r.Context(), song)
+	if err != nil {
 		h.logg.WithError(err).Error("Failed to add song")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err, "Failed to add song")
 		return
 	}
 
 	h.logg.WithFields(logrus.Fields{
-		"group": song.GroupName,
-		"song":  song.SongName,
+		"id":    created.ID,
+		"group": created.GroupName,
+		"song":  created.SongName,
 	}).Info("Song added successfully")
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/songs/"+strconv.Itoa(created.ID))
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// @Summary Получить песню
+// @Description Возвращает песню по ID. Песни в корзине не возвращаются
+// @Tags Песни
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {object} entities.Song
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id} [get]
+func (h *SongHandler) GetSong(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetSong request")
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/songs/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.logg.WithField("id", idStr).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	song, err := h.service.GetSong(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch song")
+		writeError(w, err, "Failed to fetch song")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(song)
+}
+
+// @Summary Получить текст песни по частям
+// @Description Возвращает текст песни, разбитый на части (вступление, куплеты, припевы, бридж, концовка), с пагинацией. Параметр lang выбирает перевод; с bilingual=true каждая часть оригинала выводится вместе с переводом
+// @Tags Текст
+// @Produce json
+// @Param id path int true "ID песни"
+// @Param lang query string false "Язык перевода (по умолчанию оригинал)"
+// @Param bilingual query bool false "Оригинал и перевод рядом"
+// @Param type query string false "Типы частей через запятую (intro, verse, pre-chorus, chorus, bridge, outro, other)"
+// @Param expand query bool false "Раскрыть повторы"
+// @Param mask query bool false "Скрыть ненормативную лексику звёздочками"
+// @Param page query int false "Номер страницы"
+// @Param per_page query int false "Количество частей на странице"
+// @Success 200 {object} entities.SongText
+// @Failure 400 {string} string "Неверные параметры"
+// @Failure 404 {string} string "Песня или перевод не найдены"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/text [get]
+func (h *SongHandler) GetSongText(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetSongText request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	opts := entities.TextOptions{
+		Language:      r.URL.Query().Get("lang"),
+		Bilingual:     r.URL.Query().Get("bilingual") == "true",
+		ExpandRepeats: r.URL.Query().Get("expand") == "true",
+		Mask:          r.URL.Query().Get("mask") == "true",
+		Pagination: entities.Pagination{
+			Page:    toInt(r.URL.Query().Get("page"), 1),
+			PerPage: toInt(r.URL.Query().Get("per_page"), 10),
+		},
+	}
+	if types := r.URL.Query().Get("type"); types != "" {
+		opts.Types = strings.Split(types, ",")
+	}
+	if opts.Page < 1 || opts.PerPage < 1 {
+		h.logg.WithField("options", opts).Error("Invalid pagination")
+		http.Error(w, "Invalid pagination", http.StatusBadRequest)
+		return
+	}
+
+	text, err := h.service.GetSongText(r.Context(), id, opts)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch song text")
+		writeError(w, err, "Failed to fetch song text")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(text)
 }
 
 // @Summary Получить список песен
@@ -65,6 +168,7 @@ func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param group query string false "Название группы"
 // @Param song query string false "Название песни"
+// @Param explicit query bool false "Только песни с ненормативной лексикой (true) или без неё (false)"
 // @Param page query int false "Номер страницы"
 // @Param per_page query int false "Количество элементов на странице"
 // @Success 200 {array} entities.Song
@@ -90,6 +194,10 @@ func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 		GroupName: group,
 		SongName:  song,
 	}
+	if !parseExplicitFilter(w, r, &filters) {
+		h.logg.WithField("explicit", r.URL.Query().Get("explicit")).Error("Invalid explicit filter")
+		return
+	}
 	pagination := entities.Pagination{
 		Page:    page,
 		PerPage: perPage,
@@ -98,7 +206,7 @@ func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	songs, err := h.service.GetSongs(r.Context(), filters, pagination)
 	if err != nil {
 		h.logg.WithError(err).Error("Failed to fetch songs")
-		http.Error(w, "Failed to fetch songs", http.StatusInternalServerError)
+		writeError(w, err, "Failed to fetch songs")
 		return
 	}
 
@@ -108,15 +216,125 @@ func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(songs)
 }
 
+// @Summary Экспорт песен
+// @Description Выгружает все песни, подходящие под фильтры, в CSV, NDJSON или XLSX
+// @Tags Песни
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла (csv, ndjson, xlsx)"
+// @Param group query string false "Название группы"
+// @Param song query string false "Название песни"
+// @Param explicit query bool false "Только песни с ненормативной лексикой (true) или без неё (false)"
+// @Param columns query string false "Колонки через запятую (id, group, song, release_date, link, text, allow_duplicate, explicit)"
+// @Param lyrics query bool false "Включать текст песни (по умолчанию true)"
+// @Success 200 {file} file
+// @Failure 400 {string} string "Неверный формат или колонки"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/export [get]
+func (h *SongHandler) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling ExportSongs request")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = songio.FormatCSV
+	}
+	contentType, ok := songio.ContentType(format)
+	if !ok {
+		h.logg.WithField("format", format).Error("Unsupported export format")
+		http.Error(w, "Unsupported format, expected csv, ndjson or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	columns, err := songio.Columns(r.URL.Query().Get("columns"), r.URL.Query().Get("lyrics") != "false")
+	if err != nil {
+		h.logg.WithError(err).Error("Invalid export columns")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filters := entities.SongFilters{
+		GroupName: r.URL.Query().Get("group"),
+		SongName:  r.URL.Query().Get("song"),
+	}
+	if !parseExplicitFilter(w, r, &filters) {
+		h.logg.WithField("explicit", r.URL.Query().Get("explicit")).Error("Invalid explicit filter")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="songs.%s"`, format))
+
+	out := &writeTracker{ResponseWriter: w}
+	if err := h.service.ExportSongs(r.Context(), filters, format, columns, out); err != nil {
+		h.logg.WithError(err).Error("Failed to export songs")
+		if out.written {
+			// The status line is already sent; abort the connection so the
+			// client sees a truncated download rather than a complete file.
+			panic(http.ErrAbortHandler)
+		}
+		w.Header().Del("Content-Disposition")
+		writeError(w, err, "Failed to export songs")
+	}
+}
+
+// @Summary Отметить песню как содержащую ненормативную лексику
+// @Description Редактор вручную задаёт признак explicit, который затем не меняется при обновлении текста. Значение null возвращает автоматическое определение по словарю
+// @Tags Песни
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Param explicit body entities.ExplicitRequest true "Признак explicit"
+// @Success 200 {object} entities.Song
+// @Failure 400 {string} string "Неверные данные"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/explicit [put]
+func (h *SongHandler) SetExplicit(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling SetExplicit request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var req entities.ExplicitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Failed to decode request body")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	song, err := h.service.SetExplicit(r.Context(), id, req.Explicit)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to set explicit flag")
+		writeError(w, err, "Failed to set explicit flag")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(song)
+}
+
 // @Summary Обновить информацию о песне
 // @Description Обновляет информацию о существующей песне по ID
 // @Tags Песни
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path int true "ID песни"
 // @Param song body entities.Song true "Обновлённые данные о песне"
-// @Success 200 {string} string "Песня успешно обновлена"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
+// @Success 200 {object} entities.Song
 // @Failure 400 {string} string "Неверные данные"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 409 {object} map[string]interface{} "Песня уже существует"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /songs/{id} [put]
 func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
@@ -138,22 +356,31 @@ func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	}
 	song.ID = id
 
-	if err := h.service.UpdateSong(r.Context(), song); err != nil {
+	updated, err := h.service.UpdateSong(r.Context(), song)
+	if err != nil {
 		h.logg.WithError(err).WithField("id", id).Error("Failed to update song")
-		http.Error(w, "Failed to update song", http.StatusInternalServerError)
+		writeError(w, err, "Failed to update song")
 		return
 	}
 
 	h.logg.WithField("id", id).Info("Song updated successfully")
-	w.WriteHeader(http.StatusOK)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
 }
 
 // @Summary Удалить песню
-// @Description Удаляет песню по ID
+// @Description Перемещает песню в корзину по ID. С параметром hard=true песня удаляется безвозвратно (только для администраторов)
 // @Tags Песни
+// @Security ApiKeyAuth
 // @Param id path int true "ID песни"
+// @Param hard query bool false "Удалить безвозвратно"
+// @Param Idempotency-Key header string false "Ключ идемпотентности для безопасного повтора запроса"
 // @Success 204 {string} string "Песня успешно удалена"
 // @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
 // @Failure 500 {string} string "Ошибка сервера"
 // @Router /songs/{id} [delete]
 func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
@@ -167,9 +394,14 @@ func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteSong(r.Context(), id); err != nil {
+	if hard, _ := strconv.ParseBool(r.URL.Query().Get("hard")); hard {
+		err = h.service.HardDeleteSong(r.Context(), id)
+	} else {
+		err = h.service.DeleteSong(r.Context(), id)
+	}
+	if err != nil {
 		h.logg.WithError(err).WithField("id", id).Error("Failed to delete song")
-		http.Error(w, "Failed to delete song", http.StatusInternalServerError)
+		writeError(w, err, "Failed to delete song")
 		return
 	}
 
@@ -177,9 +409,184 @@ func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// @Summary Восстановить песню из корзины
+// @Description Возвращает удалённую песню в каталог
+// @Tags Корзина
+// @Security ApiKeyAuth
+// @Param id path int true "ID песни"
+// @Success 204 {string} string "Песня восстановлена"
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песни нет в корзине"
+// @Failure 409 {object} map[string]interface{} "Песня уже существует"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/restore [post]
+func (h *SongHandler) RestoreSong(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling RestoreSong request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RestoreSong(r.Context(), id); err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to restore song")
+		writeError(w, err, "Failed to restore song")
+		return
+	}
+
+	h.logg.WithField("id", id).Info("Song restored successfully")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Получить корзину
+// @Description Возвращает удалённые песни, начиная с последних удалённых
+// @Tags Корзина
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Номер страницы"
+// @Param per_page query int false "Количество элементов на странице"
+// @Success 200 {array} entities.Song
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /trash [get]
+func (h *SongHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetTrash request")
+
+	pagination := entities.Pagination{
+		Page:    toInt(r.URL.Query().Get("page"), 1),
+		PerPage: toInt(r.URL.Query().Get("per_page"), 10),
+	}
+
+	songs, err := h.service.GetTrash(r.Context(), pagination)
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch trash")
+		writeError(w, err, "Failed to fetch trash")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(songs)
+}
+
+// @Summary Получить найденные дубликаты
+// @Description Возвращает дубликаты, обнаруженные в каталоге при включении проверки уникальности
+// @Tags Песни
+// @Produce json
+// @Success 200 {array} entities.DuplicateReport
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/duplicates [get]
+func (h *SongHandler) GetDuplicateReports(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetDuplicateReports request")
+
+	reports, err := h.service.GetDuplicateReports(r.Context())
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch duplicate reports")
+		writeError(w, err, "Failed to fetch duplicate reports")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// @Summary Предпросмотр объединения песен
+// @Description Показывает результат объединения дубликатов, не изменяя данные
+// @Tags Дубликаты
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param merge body entities.MergeRequest true "Сохраняемая песня, объединяемые песни и источники значений полей"
+// @Success 200 {object} entities.MergePreview
+// @Failure 400 {string} string "Неверные входные данные"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/merge/preview [post]
+func (h *SongHandler) PreviewMerge(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling PreviewMerge request")
+	h.merge(w, r, h.service.PreviewMerge)
+}
+
+// @Summary Объединить песни
+// @Description Объединяет дубликаты в одну песню: переносит ревизии, оставляет перенаправления со старых ID и записывает объединение в журнал
+// @Tags Дубликаты
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param merge body entities.MergeRequest true "Сохраняемая песня, объединяемые песни и источники значений полей"
+// @Success 200 {object} entities.MergePreview
+// @Failure 400 {string} string "Неверные входные данные"
+// @Failure 401 {string} string "Требуется аутентификация"
+// @Failure 403 {string} string "Недостаточно прав"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 409 {object} map[string]interface{} "Песня уже существует"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/merge [post]
+func (h *SongHandler) MergeSongs(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling MergeSongs request")
+	h.merge(w, r, h.service.MergeSongs)
+}
+
+func (h *SongHandler) merge(w http.ResponseWriter, r *http.Request, run func(context.Context, entities.MergeRequest) (entities.MergePreview, error)) {
+	var req entities.MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logg.WithError(err).Error("Invalid request payload")
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	result, err := run(r.Context(), req)
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to merge songs")
+		writeError(w, err, "Failed to merge songs")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 func toInt(value string, defaultValue int) int {
 	if i, err := strconv.Atoi(value); err == nil {
 		return i
 	}
 	return defaultValue
 }
+
+// parseExplicitFilter reads the explicit query parameter into filters and
+// answers 400 when it is not a boolean.
+func parseExplicitFilter(w http.ResponseWriter, r *http.Request, filters *entities.SongFilters) bool {
+	value := r.URL.Query().Get("explicit")
+	if value == "" {
+		return true
+	}
+	explicit, err := strconv.ParseBool(value)
+	if err != nil {
+		http.Error(w, "Invalid explicit filter, expected true or false", http.StatusBadRequest)
+		return false
+	}
+	filters.Explicit = &explicit
+	return true
+}
+
+func pathInt(r *http.Request, name string) (int, bool) {
+	i, err := strconv.Atoi(r.PathValue(name))
+	return i, err == nil && i > 0
+}
+
+// writeTracker records whether any part of the response body was sent.
+type writeTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (t *writeTracker) Write(p []byte) (int, error) {
+	t.written = true
+	return t.ResponseWriter.Write(p)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+type StatsHandler struct {
+	service services.LyricsStatsServiceInterface
+	logg    *logger.Logger
+}
+
+func NewStatsHandler(service services.LyricsStatsServiceInterface, logg *logger.Logger) *StatsHandler {
+	return &StatsHandler{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// @Summary Статистика текста песни
+// @Description Возвращает число слов, строк и куплетов, долю уникальных слов, самые частые слова без стоп-слов, примерное время чтения и повторяющиеся строки
+// @Tags Статистика
+// @Produce json
+// @Param id path int true "ID песни"
+// @Success 200 {object} entities.LyricsStats
+// @Failure 400 {string} string "Неверный ID"
+// @Failure 404 {string} string "Песня не найдена"
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /songs/{id}/stats [get]
+func (h *StatsHandler) GetSongStats(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetSongStats request")
+
+	id, ok := pathInt(r, "id")
+	if !ok {
+		h.logg.WithField("id", r.PathValue("id")).Error("Invalid ID")
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.service.GetSongStats(r.Context(), id)
+	if err != nil {
+		h.logg.WithError(err).WithField("id", id).Error("Failed to fetch lyrics statistics")
+		writeError(w, err, "Failed to fetch lyrics statistics")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// @Summary Статистика текстов каталога
+// @Description Возвращает суммарную статистику текстов всех песен, кроме удалённых в корзину
+// @Tags Статистика
+// @Produce json
+// @Success 200 {object} entities.CatalogueLyricsStats
+// @Failure 500 {string} string "Ошибка сервера"
+// @Router /stats/lyrics [get]
+func (h *StatsHandler) GetCatalogueStats(w http.ResponseWriter, r *http.Request) {
+	h.logg.WithField("method", r.Method).Debug("Handling GetCatalogueStats request")
+
+	stats, err := h.service.GetCatalogueStats(r.Context())
+	if err != nil {
+		h.logg.WithError(err).Error("Failed to fetch catalogue lyrics statistics")
+		writeError(w, err, "Failed to fetch catalogue lyrics statistics")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
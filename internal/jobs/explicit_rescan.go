@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/profanity"
+	"github.com/senyabanana/library-service/internal/repository"
+)
+
+const explicitRescanBatchSize = 1000
+
+// ExplicitRescanJob checks the lyrics of every song that is not flagged by
+// hand against the profanity dictionary once, at startup, so that songs
+// added before the dictionary changed are flagged by the current one.
+type ExplicitRescanJob struct {
+	repo       repository.SongRepositoryInterface
+	lyrics     repository.LyricsRepositoryInterface
+	dictionary *profanity.Dictionary
+	logg       *logger.Logger
+}
+
+func NewExplicitRescanJob(repo repository.SongRepositoryInterface, lyrics repository.LyricsRepositoryInterface, dictionary *profanity.Dictionary, logg *logger.Logger) *ExplicitRescanJob {
+	return &ExplicitRescanJob{
+		repo:       repo,
+		lyrics:     lyrics,
+		dictionary: dictionary,
+		logg:       logg,
+	}
+}
+
+func (j *ExplicitRescanJob) Run(ctx context.Context) {
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Name: "system:explicit-rescan", Role: auth.RoleAdmin})
+	j.logg.Info("Explicit content rescan started")
+
+	languages, err := j.lyrics.GetOriginalLanguages(ctx)
+	if err != nil {
+		j.logg.WithError(err).Error("Failed to fetch lyrics languages for explicit rescan")
+		return
+	}
+
+	var explicitIDs, cleanIDs []int
+	query := `SELECT ` + repository.SongColumns + ` FROM songs WHERE NOT explicit_manual ORDER BY id`
+	err = j.repo.StreamSongsWithQuery(ctx, func(song entities.Song) error {
+		explicit := j.dictionary.Explicit(song.Text, languages[song.ID])
+		switch {
+		case explicit && !song.Explicit:
+			explicitIDs = append(explicitIDs, song.ID)
+		case !explicit && song.Explicit:
+			cleanIDs = append(cleanIDs, song.ID)
+		}
+		return nil
+	}, query)
+	if err != nil {
+		j.logg.WithError(err).Error("Failed to scan songs for explicit content")
+		return
+	}
+
+	changed := 0
+	for len(explicitIDs) > 0 || len(cleanIDs) > 0 {
+		explicitBatch := explicitIDs[:min(len(explicitIDs), explicitRescanBatchSize)]
+		cleanBatch := cleanIDs[:min(len(cleanIDs), explicitRescanBatchSize)]
+		n, err := j.repo.UpdateExplicitFlags(ctx, explicitBatch, cleanBatch)
+		if err != nil {
+			j.logg.WithError(err).Error("Failed to update explicit flags")
+			return
+		}
+		changed += n
+		explicitIDs, cleanIDs = explicitIDs[len(explicitBatch):], cleanIDs[len(cleanBatch):]
+	}
+	j.logg.WithField("changed", changed).Info("Explicit content rescan finished")
+}
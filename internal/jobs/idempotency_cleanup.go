@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+)
+
+// IdempotencyCleanupJob deletes stored idempotent responses whose replay
+// window has passed.
+type IdempotencyCleanupJob struct {
+	repo     repository.IdempotencyRepositoryInterface
+	interval time.Duration
+	logg     *logger.Logger
+}
+
+func NewIdempotencyCleanupJob(repo repository.IdempotencyRepositoryInterface, interval time.Duration, logg *logger.Logger) *IdempotencyCleanupJob {
+	return &IdempotencyCleanupJob{
+		repo:     repo,
+		interval: interval,
+		logg:     logg,
+	}
+}
+
+func (j *IdempotencyCleanupJob) Run(ctx context.Context) {
+	j.logg.Info("Idempotency cleanup job started")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *IdempotencyCleanupJob) cleanup(ctx context.Context) {
+	deleted, err := j.repo.DeleteExpiredKeys(ctx)
+	if err != nil {
+		j.logg.WithError(err).Error("Failed to delete expired idempotency keys")
+		return
+	}
+	if deleted > 0 {
+		j.logg.WithField("count", deleted).Info("Deleted expired idempotency keys")
+	}
+}
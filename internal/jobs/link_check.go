@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	linkCheckBatchSize = 100
+	// linkCheckMaxFailures is how many inconclusive checks in a row mark a
+	// link dead.
+	linkCheckMaxFailures = 3
+)
+
+// LinkCheckJob periodically requests every song link that has not been
+// checked within the recheck period and records whether it is alive.
+type LinkCheckJob struct {
+	repo     repository.LinkRepositoryInterface
+	checker  *links.Checker
+	recheck  time.Duration
+	interval time.Duration
+	logg     *logger.Logger
+}
+
+func NewLinkCheckJob(repo repository.LinkRepositoryInterface, checker *links.Checker, recheck, interval time.Duration, logg *logger.Logger) *LinkCheckJob {
+	return &LinkCheckJob{
+		repo:     repo,
+		checker:  checker,
+		recheck:  recheck,
+		interval: interval,
+		logg:     logg,
+	}
+}
+
+func (j *LinkCheckJob) Run(ctx context.Context) {
+	j.logg.WithField("recheck", j.recheck).Info("Link check job started")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.checkDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkDue checks due links batch by batch until none are left.
+func (j *LinkCheckJob) checkDue(ctx context.Context) {
+	checked, dead := 0, 0
+	for ctx.Err() == nil {
+		due, err := j.repo.GetLinksToCheck(ctx, time.Now().Add(-j.recheck), linkCheckBatchSize)
+		if err != nil {
+			j.logg.WithError(err).Error("Failed to fetch links to check")
+			return
+		}
+		for _, link := range due {
+			j.check(ctx, &link)
+			if ctx.Err() != nil {
+				return
+			}
+			if err := j.repo.SaveCheckResult(ctx, link); err != nil {
+				j.logg.WithError(err).Error("Failed to save link check result")
+				return
+			}
+			checked++
+			if link.Status == entities.LinkStatusDead {
+				dead++
+			}
+		}
+		if len(due) < linkCheckBatchSize {
+			break
+		}
+	}
+	if checked > 0 {
+		j.logg.WithFields(logrus.Fields{
+			"checked": checked,
+			"dead":    dead,
+		}).Info("Song links checked")
+	}
+}
+
+func (j *LinkCheckJob) check(ctx context.Context, link *entities.SongLink) {
+	result := j.checker.Check(ctx, link.URL)
+	now := time.Now()
+	link.CheckedAt = &now
+	link.HTTPStatus = nil
+	if result.HTTPStatus != 0 {
+		link.HTTPStatus = &result.HTTPStatus
+	}
+
+	switch result.Verdict {
+	case links.VerdictAlive:
+		link.Status, link.Failures = entities.LinkStatusAlive, 0
+	case links.VerdictDead:
+		link.Status, link.Failures = entities.LinkStatusDead, 0
+	default:
+		link.Failures++
+		if link.Failures >= linkCheckMaxFailures {
+			link.Status = entities.LinkStatusDead
+		}
+		j.logg.WithError(result.Err).WithFields(logrus.Fields{
+			"link_id":     link.ID,
+			"url":         link.URL,
+			"status_code": result.HTTPStatus,
+			"failures":    link.Failures,
+		}).Warn("Link check was inconclusive")
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
+	"github.com/senyabanana/library-service/internal/logger"
+)
+
+// fakeLinkRepository hands out its links once and keeps the saved results.
+type fakeLinkRepository struct {
+	links []entities.SongLink
+	saved []entities.SongLink
+}
+
+func (r *fakeLinkRepository) GetLinks(context.Context, int) ([]entities.SongLink, error) {
+	return nil, nil
+}
+
+func (r *fakeLinkRepository) AddLink(_ context.Context, link entities.SongLink) (entities.SongLink, error) {
+	return link, nil
+}
+
+func (r *fakeLinkRepository) DeleteLink(context.Context, int, int) error { return nil }
+
+func (r *fakeLinkRepository) SetPrimaryLink(context.Context, int, int) (entities.SongLink, error) {
+	return entities.SongLink{}, nil
+}
+
+func (r *fakeLinkRepository) GetLinksToCheck(context.Context, time.Time, int) ([]entities.SongLink, error) {
+	due := r.links
+	r.links = nil
+	return due, nil
+}
+
+func (r *fakeLinkRepository) SaveCheckResult(_ context.Context, link entities.SongLink) error {
+	r.saved = append(r.saved, link)
+	return nil
+}
+
+func TestLinkCheckJob(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/alive":
+			w.WriteHeader(http.StatusOK)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		failures int
+		status   string
+		want     string
+		wantFail int
+	}{
+		{"alive resets failures", "/alive", 2, entities.LinkStatusUnchecked, entities.LinkStatusAlive, 0},
+		{"gone is dead at once", "/gone", 0, entities.LinkStatusAlive, entities.LinkStatusDead, 0},
+		{"first inconclusive check keeps status", "/flaky", 0, entities.LinkStatusAlive, entities.LinkStatusAlive, 1},
+		{"third inconclusive check marks dead", "/flaky", linkCheckMaxFailures - 1, entities.LinkStatusAlive, entities.LinkStatusDead, linkCheckMaxFailures},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeLinkRepository{links: []entities.SongLink{
+				{ID: 1, URL: server.URL + tt.path, Status: tt.status, Failures: tt.failures},
+			}}
+			job := NewLinkCheckJob(repo, links.NewChecker(server.Client(), "test"), time.Hour, time.Hour, logger.NewLogger())
+			job.checkDue(context.Background())
+
+			if len(repo.saved) != 1 {
+				t.Fatalf("saved %d results, want 1", len(repo.saved))
+			}
+			got := repo.saved[0]
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+			if got.Failures != tt.wantFail {
+				t.Errorf("failures = %d, want %d", got.Failures, tt.wantFail)
+			}
+			if got.CheckedAt == nil || got.HTTPStatus == nil {
+				t.Errorf("checked_at and http_status must be set, got %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/ratelimit"
+)
+
+// RateLimitCleanupJob drops rate limit counters of windows that have ended.
+type RateLimitCleanupJob struct {
+	store    ratelimit.Store
+	interval time.Duration
+	logg     *logger.Logger
+}
+
+func NewRateLimitCleanupJob(store ratelimit.Store, interval time.Duration, logg *logger.Logger) *RateLimitCleanupJob {
+	return &RateLimitCleanupJob{
+		store:    store,
+		interval: interval,
+		logg:     logg,
+	}
+}
+
+func (j *RateLimitCleanupJob) Run(ctx context.Context) {
+	j.logg.Info("Rate limit cleanup job started")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := j.store.DeleteExpired(ctx)
+		if err != nil {
+			j.logg.WithError(err).Error("Failed to delete expired rate limit counters")
+			continue
+		}
+		if deleted > 0 {
+			j.logg.WithField("count", deleted).Debug("Deleted expired rate limit counters")
+		}
+	}
+}
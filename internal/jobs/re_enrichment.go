@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/api"
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+	"github.com/senyabanana/library-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReEnrichmentJob refreshes songs that were last enriched longer than
+// staleAfter ago. Each run refreshes at most batchSize songs, one every
+// pause, so that upstream providers are not flooded.
+type ReEnrichmentJob struct {
+	repo       repository.EnrichmentRepositoryInterface
+	service    services.EnrichmentServiceInterface
+	staleAfter time.Duration
+	interval   time.Duration
+	batchSize  int
+	pause      time.Duration
+	logg       *logger.Logger
+}
+
+func NewReEnrichmentJob(repo repository.EnrichmentRepositoryInterface, service services.EnrichmentServiceInterface, staleAfter, interval time.Duration, batchSize int, pause time.Duration, logg *logger.Logger) *ReEnrichmentJob {
+	return &ReEnrichmentJob{
+		repo:       repo,
+		service:    service,
+		staleAfter: staleAfter,
+		interval:   interval,
+		batchSize:  batchSize,
+		pause:      pause,
+		logg:       logg,
+	}
+}
+
+func (j *ReEnrichmentJob) Run(ctx context.Context) {
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Name: "system:re-enrichment", Role: auth.RoleAdmin})
+	ctx = api.WithPriority(ctx, api.PriorityBatch)
+	j.logg.WithFields(logrus.Fields{
+		"stale_after": j.staleAfter,
+		"batch_size":  j.batchSize,
+	}).Info("Re-enrichment job started")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.refreshBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *ReEnrichmentJob) refreshBatch(ctx context.Context) {
+	ids, err := j.repo.GetStaleSongIDs(ctx, time.Now().Add(-j.staleAfter), j.batchSize)
+	if err != nil {
+		j.logg.WithError(err).Error("Failed to fetch stale songs")
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	pace := time.NewTicker(j.pause)
+	defer pace.Stop()
+
+	updated, reviews, failed := 0, 0, 0
+	for i, id := range ids {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-pace.C:
+			}
+		}
+		result, err := j.service.RefreshSong(ctx, id)
+		if err != nil {
+			// RefreshSong has put off the next attempt, so the song does
+			// not take the place of other stale songs meanwhile.
+			failed++
+			j.logg.WithError(err).WithField("song_id", id).Warn("Failed to refresh song")
+			continue
+		}
+		if len(result.Updated) > 0 {
+			updated++
+		}
+		reviews += len(result.Reviews)
+	}
+
+	j.logg.WithFields(logrus.Fields{
+		"checked": len(ids),
+		"updated": updated,
+		"reviews": reviews,
+		"failed":  failed,
+	}).Info("Re-enrichment batch finished")
+}
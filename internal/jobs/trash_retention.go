@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+)
+
+// TrashRetentionJob permanently deletes songs that have been in the trash
+// for longer than the retention period.
+type TrashRetentionJob struct {
+	repo      repository.SongRepositoryInterface
+	retention time.Duration
+	interval  time.Duration
+	logg      *logger.Logger
+}
+
+func NewTrashRetentionJob(repo repository.SongRepositoryInterface, retention, interval time.Duration, logg *logger.Logger) *TrashRetentionJob {
+	return &TrashRetentionJob{
+		repo:      repo,
+		retention: retention,
+		interval:  interval,
+		logg:      logg,
+	}
+}
+
+func (j *TrashRetentionJob) Run(ctx context.Context) {
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Name: "system:trash-retention", Role: auth.RoleAdmin})
+	j.logg.WithField("retention", j.retention).Info("Trash retention job started")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *TrashRetentionJob) purge(ctx context.Context) {
+	purged, err := j.repo.PurgeDeletedSongs(ctx, time.Now().Add(-j.retention))
+	if err != nil {
+		j.logg.WithError(err).Error("Failed to purge trashed songs")
+		return
+	}
+	if purged > 0 {
+		j.logg.WithField("count", purged).Info("Purged songs past trash retention")
+	}
+}
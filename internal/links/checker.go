@@ -0,0 +1,86 @@
+package links
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	VerdictAlive   = "alive"
+	VerdictDead    = "dead"
+	VerdictUnknown = "unknown"
+)
+
+// CheckResult is the outcome of one check. HTTPStatus is 0 when no response
+// was received.
+type CheckResult struct {
+	Verdict    string
+	HTTPStatus int
+	Err        error
+}
+
+// Checker requests links to see whether they still lead anywhere.
+type Checker struct {
+	client    *http.Client
+	userAgent string
+}
+
+// NewChecker uses client for requests, or a client with a 10 second timeout
+// when client is nil.
+func NewChecker(client *http.Client, userAgent string) *Checker {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Checker{
+		client:    client,
+		userAgent: userAgent,
+	}
+}
+
+// Check sends a HEAD request to link, falling back to GET when the server
+// answers HEAD with an error, since many servers do not implement it. A
+// link is dead on 404 or 410 and alive on any other response below 500
+// except 429, as 401 and 403 mean the page exists behind a login or a bot
+// filter. Server errors, rate limiting and network errors are not proof
+// either way.
+func (c *Checker) Check(ctx context.Context, link string) CheckResult {
+	status, err := c.request(ctx, http.MethodHead, link)
+	if err != nil || status >= 400 {
+		status, err = c.request(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		return CheckResult{Verdict: VerdictUnknown, Err: err}
+	}
+
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return CheckResult{Verdict: VerdictDead, HTTPStatus: status}
+	case status == http.StatusTooManyRequests || status >= 500:
+		return CheckResult{Verdict: VerdictUnknown, HTTPStatus: status}
+	default:
+		return CheckResult{Verdict: VerdictAlive, HTTPStatus: status}
+	}
+}
+
+func (c *Checker) request(ctx context.Context, method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
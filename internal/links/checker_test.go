@@ -0,0 +1,92 @@
+package links
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckerVerdicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		verdict string
+	}{
+		{"ok", http.StatusOK, VerdictAlive},
+		{"partial content", http.StatusPartialContent, VerdictAlive},
+		{"unauthorized", http.StatusUnauthorized, VerdictAlive},
+		{"forbidden", http.StatusForbidden, VerdictAlive},
+		{"not found", http.StatusNotFound, VerdictDead},
+		{"gone", http.StatusGone, VerdictDead},
+		{"too many requests", http.StatusTooManyRequests, VerdictUnknown},
+		{"internal server error", http.StatusInternalServerError, VerdictUnknown},
+		{"bad gateway", http.StatusBadGateway, VerdictUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			result := NewChecker(server.Client(), "test").Check(context.Background(), server.URL)
+			if result.Verdict != tt.verdict {
+				t.Errorf("verdict = %q, want %q", result.Verdict, tt.verdict)
+			}
+			if result.HTTPStatus != tt.status {
+				t.Errorf("status = %d, want %d", result.HTTPStatus, tt.status)
+			}
+		})
+	}
+}
+
+func TestCheckerFallsBackToGet(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("Range = %q, want bytes=0-0", r.Header.Get("Range"))
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	result := NewChecker(server.Client(), "test").Check(context.Background(), server.URL)
+	if result.Verdict != VerdictAlive || result.HTTPStatus != http.StatusOK {
+		t.Errorf("result = %+v, want alive with 200", result)
+	}
+	if len(methods) != 2 || methods[0] != http.MethodHead || methods[1] != http.MethodGet {
+		t.Errorf("methods = %v, want [HEAD GET]", methods)
+	}
+}
+
+func TestCheckerNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	result := NewChecker(nil, "test").Check(context.Background(), url)
+	if result.Verdict != VerdictUnknown {
+		t.Errorf("verdict = %q, want %q", result.Verdict, VerdictUnknown)
+	}
+	if result.Err == nil || result.HTTPStatus != 0 {
+		t.Errorf("result = %+v, want an error and no status", result)
+	}
+}
+
+func TestCheckerSendsUserAgent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("User-Agent"); got != "library-service link checker" {
+			t.Errorf("User-Agent = %q", got)
+		}
+	}))
+	defer server.Close()
+
+	NewChecker(server.Client(), "library-service link checker").Check(context.Background(), server.URL)
+}
@@ -0,0 +1,163 @@
+package links
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	ProviderYouTube      = "youtube"
+	ProviderYouTubeMusic = "youtube_music"
+	ProviderSpotify      = "spotify"
+	ProviderAppleMusic   = "apple_music"
+	ProviderBandcamp     = "bandcamp"
+	ProviderSoundCloud   = "soundcloud"
+	ProviderDeezer       = "deezer"
+	ProviderTidal        = "tidal"
+	ProviderYandexMusic  = "yandex_music"
+	ProviderVK           = "vk"
+	ProviderOther        = "other"
+)
+
+var ErrInvalid = errors.New("invalid link")
+
+var providerHosts = map[string]string{
+	"youtube.com":       ProviderYouTube,
+	"youtu.be":          ProviderYouTube,
+	"music.youtube.com": ProviderYouTubeMusic,
+	"open.spotify.com":  ProviderSpotify,
+	"spotify.com":       ProviderSpotify,
+	"music.apple.com":   ProviderAppleMusic,
+	"itunes.apple.com":  ProviderAppleMusic,
+	"bandcamp.com":      ProviderBandcamp,
+	"soundcloud.com":    ProviderSoundCloud,
+	"deezer.com":        ProviderDeezer,
+	"tidal.com":         ProviderTidal,
+	"music.yandex.ru":   ProviderYandexMusic,
+	"music.yandex.com":  ProviderYandexMusic,
+	"music.yandex.by":   ProviderYandexMusic,
+	"music.yandex.kz":   ProviderYandexMusic,
+	"vk.com":            ProviderVK,
+	"vk.ru":             ProviderVK,
+}
+
+// trackingParams are dropped from query strings of every link, and
+// providerTrackingParams only from links of that provider. A trailing "*"
+// matches any parameter with that prefix.
+var (
+	trackingParams = []string{
+		"utm_*", "fbclid", "gclid", "dclid", "yclid", "msclkid", "igshid", "mc_cid", "mc_eid", "_ga", "_gl", "ref_src",
+	}
+	providerTrackingParams = map[string][]string{
+		ProviderYouTube:      {"si", "feature", "pp", "ab_channel"},
+		ProviderYouTubeMusic: {"si", "feature"},
+		ProviderSpotify:      {"si", "context", "nd", "go", "sp_cid"},
+		ProviderAppleMusic:   {"ls", "app", "uo", "at", "ct", "itsct", "itscg"},
+		ProviderSoundCloud:   {"si", "ref", "p", "c"},
+		ProviderBandcamp:     {"from", "search_*"},
+		ProviderDeezer:       {"deferredFl", "af_*"},
+	}
+)
+
+// Detect returns the provider of a link from its host, or ProviderOther.
+func Detect(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return ProviderOther
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	for {
+		if provider, ok := providerHosts[host]; ok {
+			return provider
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 || !strings.Contains(host[dot+1:], ".") {
+			return ProviderOther
+		}
+		host = host[dot+1:]
+	}
+}
+
+// Canonicalize checks that link is an absolute http or https URL and returns
+// it in a canonical form: lower-case scheme and host without "www." or
+// "m.", no default port, fragment or tracking parameters, remaining query
+// parameters sorted, and no trailing slash. youtu.be short links become
+// youtube.com/watch links and Spotify locale prefixes are dropped.
+func Canonicalize(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	if link == "" || strings.ContainsAny(link, " \t\n") {
+		return "", ErrInvalid
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "" || u.User != nil || u.Opaque != "" {
+		return "", ErrInvalid
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if !strings.Contains(host, ".") && net.ParseIP(host) == nil && host != "localhost" {
+		return "", ErrInvalid
+	}
+	host = strings.TrimPrefix(host, "www.")
+	if strings.HasPrefix(host, "m.") && strings.Count(host, ".") > 1 {
+		host = host[2:]
+	}
+	port := u.Port()
+	if u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443" {
+		port = ""
+	}
+
+	provider := Detect(u.Scheme + "://" + host)
+	query := u.Query()
+	for key := range query {
+		if matchParam(trackingParams, key) || matchParam(providerTrackingParams[provider], key) {
+			query.Del(key)
+		}
+	}
+
+	path := u.EscapedPath()
+	switch provider {
+	case ProviderYouTube:
+		if host == "youtu.be" && len(path) > 1 {
+			query.Set("v", strings.Trim(path, "/"))
+			host, path = "youtube.com", "/watch"
+		}
+	case ProviderSpotify:
+		if segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2); len(segments) == 2 && strings.HasPrefix(segments[0], "intl-") {
+			path = "/" + segments[1]
+		}
+	}
+	path = strings.TrimSuffix(path, "/")
+
+	var b strings.Builder
+	b.WriteString(u.Scheme + "://")
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		host = "[" + host + "]"
+	}
+	b.WriteString(host)
+	if port != "" {
+		b.WriteString(":" + port)
+	}
+	b.WriteString(path)
+	if len(query) > 0 {
+		b.WriteString("?" + query.Encode())
+	}
+	return b.String(), nil
+}
+
+func matchParam(params []string, key string) bool {
+	key = strings.ToLower(key)
+	for _, param := range params {
+		param = strings.ToLower(param)
+		if prefix, ok := strings.CutSuffix(param, "*"); ok && strings.HasPrefix(key, prefix) || key == param {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+var (
+	timeTag   = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	wordTag   = regexp.MustCompile(`<(\d+):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+	metaTag   = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]$`)
+	metaOrder = []string{"ar", "ti", "al", "au", "by", "length"}
+)
+
+// ParseLRC reads LRC and enhanced LRC. Lines with several time tags are
+// repeated at each time, the [offset:] tag is applied to all times, and end
+// times are taken from the start of the next line and, for the last line,
+// from the [length:] tag.
+func ParseLRC(data string) (entities.SyncedLyrics, error) {
+	result := entities.SyncedLyrics{Metadata: map[string]string{}}
+	var lines []entities.LyricLine
+
+	data = strings.TrimPrefix(data, "\ufeff")
+	for n, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		var starts []int64
+		rest := raw
+		for {
+			m := timeTag.FindStringSubmatch(rest)
+			if m == nil {
+				break
+			}
+			start, err := parseTime(m[1], m[2], m[3])
+			if err != nil {
+				return entities.SyncedLyrics{}, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			starts = append(starts, start)
+			rest = rest[len(m[0]):]
+		}
+
+		if len(starts) == 0 {
+			if m := metaTag.FindStringSubmatch(raw); m != nil {
+				result.Metadata[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+			}
+			continue
+		}
+
+		text, words, err := parseWords(strings.TrimSpace(rest), starts[0])
+		if err != nil {
+			return entities.SyncedLyrics{}, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		for _, start := range starts {
+			lines = append(lines, entities.LyricLine{StartMS: start, Text: text, Words: shiftWords(words, start-starts[0])})
+		}
+	}
+	if len(lines) == 0 {
+		return entities.SyncedLyrics{}, errors.New("no timestamped lines found")
+	}
+
+	if offset, ok := result.Metadata["offset"]; ok {
+		ms, err := strconv.ParseInt(strings.TrimPrefix(offset, "+"), 10, 64)
+		if err != nil {
+			return entities.SyncedLyrics{}, fmt.Errorf("invalid offset %q", offset)
+		}
+		// A positive offset makes lyrics appear sooner.
+		for i := range lines {
+			lines[i].StartMS = max(lines[i].StartMS-ms, 0)
+			for j := range lines[i].Words {
+				lines[i].Words[j].StartMS = max(lines[i].Words[j].StartMS-ms, 0)
+			}
+		}
+		delete(result.Metadata, "offset")
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].StartMS < lines[j].StartMS })
+	for i := 0; i < len(lines)-1; i++ {
+		end := lines[i+1].StartMS
+		lines[i].EndMS = &end
+	}
+	if length, ok := result.Metadata["length"]; ok {
+		if end, err := parseLength(length); err == nil && end > lines[len(lines)-1].StartMS {
+			lines[len(lines)-1].EndMS = &end
+		}
+	}
+
+	result.Lines = lines
+	return result, nil
+}
+
+// FormatLRC writes lyrics as LRC; with enhanced, word timings are included
+// where known.
+func FormatLRC(lyrics entities.SyncedLyrics, enhanced bool) string {
+	var b strings.Builder
+
+	written := map[string]bool{}
+	for _, key := range metaOrder {
+		if value, ok := lyrics.Metadata[key]; ok {
+			fmt.Fprintf(&b, "[%s:%s]\n", key, value)
+			written[key] = true
+		}
+	}
+	var other []string
+	for key := range lyrics.Metadata {
+		if !written[key] {
+			other = append(other, key)
+		}
+	}
+	sort.Strings(other)
+	for _, key := range other {
+		fmt.Fprintf(&b, "[%s:%s]\n", key, lyrics.Metadata[key])
+	}
+
+	for _, line := range lyrics.Lines {
+		b.WriteString("[" + formatTime(line.StartMS) + "]")
+		if enhanced && len(line.Words) > 0 {
+			for i, word := range line.Words {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString("<" + formatTime(word.StartMS) + ">" + word.Text)
+			}
+		} else {
+			b.WriteString(line.Text)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// LineAt returns the index of the line shown at position ms, or false if no
+// line is active then.
+func LineAt(lines []entities.LyricLine, ms int64) (int, bool) {
+	i := sort.Search(len(lines), func(i int) bool { return lines[i].StartMS > ms }) - 1
+	if i < 0 {
+		return 0, false
+	}
+	if end := lines[i].EndMS; end != nil && ms >= *end {
+		return 0, false
+	}
+	return i, true
+}
+
+// parseWords strips enhanced LRC word tags from text and returns the words
+// with their times, which must not go back from lineStart or from each
+// other. Plain lines have no words.
+func parseWords(text string, lineStart int64) (string, []entities.LyricWord, error) {
+	tags := wordTag.FindAllStringSubmatchIndex(text, -1)
+	if len(tags) == 0 {
+		return text, nil, nil
+	}
+
+	var words []entities.LyricWord
+	plain := make([]string, 0, len(tags))
+	prev := lineStart
+	for i, tag := range tags {
+		m := text[tag[0]:tag[1]]
+		parts := wordTag.FindStringSubmatch(m)
+		start, err := parseTime(parts[1], parts[2], parts[3])
+		if err != nil {
+			return "", nil, err
+		}
+		if start < prev {
+			return "", nil, fmt.Errorf("word time %s is before %s", formatTime(start), formatTime(prev))
+		}
+		prev = start
+		end := len(text)
+		if i+1 < len(tags) {
+			end = tags[i+1][0]
+		}
+		word := strings.TrimSpace(text[tag[1]:end])
+		if word == "" {
+			continue
+		}
+		words = append(words, entities.LyricWord{StartMS: start, Text: word})
+		plain = append(plain, word)
+	}
+	if prefix := strings.TrimSpace(text[:tags[0][0]]); prefix != "" {
+		plain = append([]string{prefix}, plain...)
+	}
+	return strings.Join(plain, " "), words, nil
+}
+
+// shiftWords moves word times along with a repeated line; delta is the
+// distance from the first time tag of the line.
+func shiftWords(words []entities.LyricWord, delta int64) []entities.LyricWord {
+	if len(words) == 0 {
+		return nil
+	}
+	shifted := make([]entities.LyricWord, len(words))
+	for i, word := range words {
+		shifted[i] = entities.LyricWord{StartMS: word.StartMS + delta, Text: word.Text}
+	}
+	return shifted
+}
+
+func parseTime(minutes, seconds, fraction string) (int64, error) {
+	m, _ := strconv.ParseInt(minutes, 10, 64)
+	s, _ := strconv.ParseInt(seconds, 10, 64)
+	if s >= 60 {
+		return 0, fmt.Errorf("invalid time %s:%s", minutes, seconds)
+	}
+	ms := (m*60 + s) * 1000
+	if fraction != "" {
+		f, _ := strconv.ParseInt(fraction, 10, 64)
+		switch len(fraction) {
+		case 1:
+			f *= 100
+		case 2:
+			f *= 10
+		}
+		ms += f
+	}
+	return ms, nil
+}
+
+// parseLength reads the [length:] tag, written as mm:ss or mm:ss.xx.
+func parseLength(value string) (int64, error) {
+	m := timeTag.FindStringSubmatch("[" + strings.TrimSpace(value) + "]")
+	if m == nil {
+		return 0, fmt.Errorf("invalid length %q", value)
+	}
+	return parseTime(m[1], m[2], m[3])
+}
+
+func formatTime(ms int64) string {
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// maxPosition bounds playback positions, far above the length of any song.
+const maxPosition = 24 * 60 * 60 * 1000
+
+// ParsePosition reads a playback position given in seconds ("83.5") or as
+// mm:ss.xx ("1:23.50") and returns it in milliseconds.
+func ParsePosition(value string) (int64, error) {
+	invalid := fmt.Errorf("invalid position %q, expected seconds or mm:ss.xx up to 24 hours", value)
+	if m := timeTag.FindStringSubmatch("[" + value + "]"); m != nil {
+		ms, err := parseTime(m[1], m[2], m[3])
+		if err != nil || len(m[1]) > 4 || ms > maxPosition {
+			return 0, invalid
+		}
+		return ms, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) || seconds < 0 || seconds*1000 > maxPosition {
+		return 0, invalid
+	}
+	return int64(seconds * 1000), nil
+}
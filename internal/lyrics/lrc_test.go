@@ -0,0 +1,131 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"83.5", 83500, false},
+		{"0", 0, false},
+		{"1:23.50", 83500, false},
+		{"01:23", 83000, false},
+		{"86400", 86400000, false},
+		{"", 0, true},
+		{"-1", 0, true},
+		{"abc", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"-Inf", 0, true},
+		{"1e300", 0, true},
+		{"86400.001", 0, true},
+		{"1441:00", 0, true},
+		{"99999999999999999999:00", 0, true},
+		{"1:60", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParsePosition(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParsePosition(%q) = %d, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePosition(%q): %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePosition(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	lrc := strings.Join([]string{
+		"[ti:Starlight]",
+		"[length:00:20.00]",
+		"[00:12.00][00:02.00]Far away",
+		"[00:05.50]<00:05.50>This <00:06.00>ship <00:06.50>is",
+		"",
+		"not a line",
+	}, "\n")
+
+	synced, err := ParseLRC(lrc)
+	if err != nil {
+		t.Fatalf("ParseLRC: %v", err)
+	}
+	if synced.Metadata["ti"] != "Starlight" {
+		t.Errorf("metadata = %v, want ti Starlight", synced.Metadata)
+	}
+
+	want := []struct {
+		start, end int64
+		text       string
+		words      int
+	}{
+		{2000, 5500, "Far away", 0},
+		{5500, 12000, "This ship is", 3},
+		{12000, 20000, "Far away", 0},
+	}
+	if len(synced.Lines) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(synced.Lines), len(want), synced.Lines)
+	}
+	for i, w := range want {
+		line := synced.Lines[i]
+		if line.StartMS != w.start || line.EndMS == nil || *line.EndMS != w.end || line.Text != w.text || len(line.Words) != w.words {
+			t.Errorf("line %d = %+v, want %+v", i, line, w)
+		}
+	}
+	if words := synced.Lines[1].Words; words[2].StartMS != 6500 || words[2].Text != "is" {
+		t.Errorf("words = %+v", words)
+	}
+}
+
+func TestParseLRCOffsetAndRepeatedWords(t *testing.T) {
+	synced, err := ParseLRC("[offset:+500]\n[00:01.00][00:10.00]<00:01.00>a <00:01.50>b")
+	if err != nil {
+		t.Fatalf("ParseLRC: %v", err)
+	}
+	if len(synced.Lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(synced.Lines))
+	}
+	second := synced.Lines[1]
+	if second.StartMS != 9500 || second.Words[0].StartMS != 9500 || second.Words[1].StartMS != 10000 {
+		t.Errorf("repeated line = %+v, want words shifted with the line and the offset applied", second)
+	}
+	if _, ok := synced.Metadata["offset"]; ok {
+		t.Error("offset is kept in the metadata after being applied")
+	}
+}
+
+func TestParseLRCErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		lrc  string
+		want string
+	}{
+		{"no lines", "[ti:Starlight]\nplain text", "no timestamped lines"},
+		{"invalid line time", "[00:01.00]a\n[00:61.00]b", "line 2: invalid time"},
+		{"invalid word time", "[00:01.00]<00:75.00>a", "line 1: invalid time"},
+		{"word before line", "[00:01.00]<00:00.50>a", "line 1: word time 00:00.50 is before 00:01.00"},
+		{"words out of order", "[00:00.10]x\n[00:01.00]<00:01.00>a <00:00.50>b", "line 2: word time 00:00.50 is before 00:01.00"},
+		{"invalid offset", "[offset:soon]\n[00:01.00]a", "invalid offset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLRC(tt.lrc)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package lyrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+var (
+	bracketHeader = regexp.MustCompile(`^\[([^\]]+)\](.*)$`)
+	parenHeader   = regexp.MustCompile(`^\(([^)]+)\)(.*)$`)
+	repeatSuffix  = regexp.MustCompile(`(?i)(?:\s+|\s*[(\[])\s*(?:[x×х]\s*(\d+)|(\d+)\s*(?:[x×х]|times|раза?))\s*[)\]]?\s*$`)
+	repeatLine    = regexp.MustCompile(`(?i)^[(\[]?\s*(?:[x×х]\s*(\d+)|(\d+)\s*(?:[x×х]|times|раза?))\s*[)\]]?$`)
+	labelKeyword  = regexp.MustCompile(`(?i)^(?:repeat\s+|повтор\s+)?([\p{L}\- ]+?)\s*#?(\d+)?\s*(?::.*)?$`)
+	labelNumber   = regexp.MustCompile(`\d+`)
+)
+
+var sectionKeywords = map[string]string{
+	"intro":       entities.SectionIntro,
+	"интро":       entities.SectionIntro,
+	"вступление":  entities.SectionIntro,
+	"verse":       entities.SectionVerse,
+	"куплет":      entities.SectionVerse,
+	"prechorus":   entities.SectionPreChorus,
+	"предприпев":  entities.SectionPreChorus,
+	"chorus":      entities.SectionChorus,
+	"refrain":     entities.SectionChorus,
+	"hook":        entities.SectionChorus,
+	"припев":      entities.SectionChorus,
+	"bridge":      entities.SectionBridge,
+	"middleeight": entities.SectionBridge,
+	"бридж":       entities.SectionBridge,
+	"outro":       entities.SectionOutro,
+	"coda":        entities.SectionOutro,
+	"аутро":       entities.SectionOutro,
+	"кода":        entities.SectionOutro,
+	"концовка":    entities.SectionOutro,
+}
+
+// NormalizeText strips a byte order mark and converts CRLF and CR line
+// endings to LF.
+func NormalizeText(text string) string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// ParseSections splits lyrics into sections. A section starts at a label
+// line such as "[Chorus]", "[Verse 2: Artist]" or "Припев:", or after an
+// empty line; unlabelled sections are verses unless they repeat an earlier
+// section word for word. A repeat count ("[Chorus x2]", or a "(x2)" line
+// inside the section) sets Repeat, and a label without lines of its own
+// stands for the last section with that label; a repeat line right after
+// such a label, even past an empty line, sets its Repeat.
+func ParseSections(text string) []entities.LyricSection {
+	sections := []entities.LyricSection{}
+	var current *entities.LyricSection
+	// closedRef is set while the last section is a bare reference and no
+	// section has been started since.
+	closedRef := false
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		section := *current
+		current = nil
+
+		closedRef = false
+		if len(section.Lines) == 0 {
+			if ref := findSection(sections, section); ref != nil {
+				section.Lines = append([]string{}, ref.Lines...)
+				section.RepeatOf = ref.Number
+				closedRef = true
+			} else {
+				section.Lines = []string{}
+			}
+		} else if section.Label == "" {
+			for _, prev := range sections {
+				if prev.RepeatOf == 0 && equalLines(prev.Lines, section.Lines) {
+					section.Type, section.Label, section.RepeatOf = prev.Type, prev.Label, prev.Number
+					break
+				}
+			}
+		}
+		section.Number = len(sections) + 1
+		sections = append(sections, section)
+	}
+
+	for _, line := range strings.Split(NormalizeText(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// A label followed by an empty line still owns the lines below
+			// it, unless it refers back to an earlier section.
+			if current != nil && len(current.Lines) == 0 && current.Label != "" && findSection(sections, *current) == nil {
+				continue
+			}
+			flush()
+			continue
+		}
+
+		if n, ok := parseRepeat(repeatLine.FindStringSubmatch(line)); ok {
+			switch {
+			case current != nil && (len(current.Lines) > 0 || current.Label != "" && findSection(sections, *current) != nil):
+				current.Repeat = n
+				continue
+			case current == nil && closedRef:
+				sections[len(sections)-1].Repeat = n
+				closedRef = false
+				continue
+			}
+		}
+		if header, ok := parseHeader(line); ok {
+			flush()
+			current = &header
+			continue
+		}
+
+		if current == nil {
+			current = &entities.LyricSection{Type: entities.SectionVerse, Repeat: 1}
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	flush()
+	return sections
+}
+
+// ExpandRepeats writes out every repeated section as many times as it is
+// sung and renumbers the result. RepeatOf is renumbered to match.
+func ExpandRepeats(sections []entities.LyricSection) []entities.LyricSection {
+	expanded := []entities.LyricSection{}
+	numbers := make(map[int]int, len(sections))
+	for _, section := range sections {
+		numbers[section.Number] = len(expanded) + 1
+		if section.RepeatOf != 0 {
+			section.RepeatOf = numbers[section.RepeatOf]
+		}
+		for i := 0; i < max(section.Repeat, 1); i++ {
+			copied := section
+			copied.Number = len(expanded) + 1
+			copied.Repeat = 1
+			if i > 0 {
+				copied.RepeatOf = numbers[section.Number]
+				if section.RepeatOf != 0 {
+					copied.RepeatOf = section.RepeatOf
+				}
+			}
+			expanded = append(expanded, copied)
+		}
+	}
+	return expanded
+}
+
+// IsSectionType reports whether value is a section type ParseSections
+// assigns.
+func IsSectionType(value string) bool {
+	switch value {
+	case entities.SectionIntro, entities.SectionVerse, entities.SectionPreChorus, entities.SectionChorus,
+		entities.SectionBridge, entities.SectionOutro, entities.SectionOther:
+		return true
+	}
+	return false
+}
+
+func parseHeader(line string) (entities.LyricSection, bool) {
+	if repeatLine.MatchString(line) {
+		return entities.LyricSection{}, false
+	}
+	label, rest, bracketed := line, "", false
+	if m := bracketHeader.FindStringSubmatch(line); m != nil {
+		label, rest, bracketed = m[1], m[2], true
+	} else if m := parenHeader.FindStringSubmatch(line); m != nil {
+		label, rest = m[1], m[2]
+	}
+
+	repeat := 1
+	if strings.TrimSpace(rest) != "" {
+		n, ok := parseRepeat(repeatLine.FindStringSubmatch(strings.TrimSpace(rest)))
+		if !ok {
+			return entities.LyricSection{}, false
+		}
+		repeat = n
+	}
+	if m := repeatSuffix.FindStringSubmatchIndex(label); m != nil && m[0] > 0 {
+		n, _ := parseRepeat(repeatSuffix.FindStringSubmatch(label))
+		repeat, label = n, label[:m[0]]
+	}
+	label = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(label), ":"))
+	if label == "" {
+		return entities.LyricSection{}, false
+	}
+
+	typ := entities.SectionOther
+	if m := labelKeyword.FindStringSubmatch(label); m != nil {
+		key := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(m[1]))
+		if known, ok := sectionKeywords[key]; ok {
+			typ = known
+		}
+	}
+	// Outside square brackets only bare known labels count, so that
+	// ordinary lines and ad-libs in parentheses stay lyrics.
+	if typ == entities.SectionOther && !bracketed || !bracketed && strings.Contains(label, ":") {
+		return entities.LyricSection{}, false
+	}
+	return entities.LyricSection{Type: typ, Label: label, Repeat: repeat}, true
+}
+
+func parseRepeat(m []string) (int, bool) {
+	if m == nil {
+		return 0, false
+	}
+	value := m[1]
+	if value == "" {
+		value = m[2]
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// findSection returns the section a bare label refers to: the last one of the
+// same type whose label carries the same number, if the reference has one.
+func findSection(sections []entities.LyricSection, ref entities.LyricSection) *entities.LyricSection {
+	number := labelNumber.FindString(ref.Label)
+	for i := len(sections) - 1; i >= 0; i-- {
+		prev := &sections[i]
+		if prev.Type != ref.Type || len(prev.Lines) == 0 {
+			continue
+		}
+		if ref.Type == entities.SectionOther && !strings.EqualFold(prev.Label, ref.Label) {
+			continue
+		}
+		if number != "" && labelNumber.FindString(prev.Label) != number {
+			continue
+		}
+		if prev.RepeatOf != 0 {
+			return &sections[prev.RepeatOf-1]
+		}
+		return prev
+	}
+	return nil
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+func section(number int, typ, label string, repeat, repeatOf int, lines ...string) entities.LyricSection {
+	if lines == nil {
+		lines = []string{}
+	}
+	return entities.LyricSection{Number: number, Type: typ, Label: label, Repeat: repeat, RepeatOf: repeatOf, Lines: lines}
+}
+
+func TestParseSections(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []entities.LyricSection
+	}{
+		{
+			name: "labels and a bare reference",
+			text: "[Verse 1]\na\nb\n\n[Chorus x2]\nla\n\n[Verse 2]\nc\n\n[Chorus]",
+			want: []entities.LyricSection{
+				section(1, entities.SectionVerse, "Verse 1", 1, 0, "a", "b"),
+				section(2, entities.SectionChorus, "Chorus", 2, 0, "la"),
+				section(3, entities.SectionVerse, "Verse 2", 1, 0, "c"),
+				section(4, entities.SectionChorus, "Chorus", 1, 2, "la"),
+			},
+		},
+		{
+			name: "reference by number",
+			text: "[Verse 1]\na\n\n[Verse 2]\nb\n\n[Verse 1]",
+			want: []entities.LyricSection{
+				section(1, entities.SectionVerse, "Verse 1", 1, 0, "a"),
+				section(2, entities.SectionVerse, "Verse 2", 1, 0, "b"),
+				section(3, entities.SectionVerse, "Verse 1", 1, 1, "a"),
+			},
+		},
+		{
+			name: "label followed by an empty line owns the lines below",
+			text: "[Intro]\n\nhello\n(x2)",
+			want: []entities.LyricSection{
+				section(1, entities.SectionIntro, "Intro", 2, 0, "hello"),
+			},
+		},
+		{
+			name: "repeat line after a reference",
+			text: "[Chorus]\nla la\n\n[Chorus]\n(x3)",
+			want: []entities.LyricSection{
+				section(1, entities.SectionChorus, "Chorus", 1, 0, "la la"),
+				section(2, entities.SectionChorus, "Chorus", 3, 1, "la la"),
+			},
+		},
+		{
+			name: "repeat line after a reference and an empty line",
+			text: "[Chorus]\nla la\n\n[Chorus]\n\n(x3)",
+			want: []entities.LyricSection{
+				section(1, entities.SectionChorus, "Chorus", 1, 0, "la la"),
+				section(2, entities.SectionChorus, "Chorus", 3, 1, "la la"),
+			},
+		},
+		{
+			name: "only one repeat line applies to a reference",
+			text: "[Chorus]\nla\n\n[Chorus]\n\n(x3)\n(x4)",
+			want: []entities.LyricSection{
+				section(1, entities.SectionChorus, "Chorus", 1, 0, "la"),
+				section(2, entities.SectionChorus, "Chorus", 3, 1, "la"),
+				section(3, entities.SectionVerse, "", 1, 0, "(x4)"),
+			},
+		},
+		{
+			name: "cyrillic labels and repeat markers",
+			text: "Куплет 1:\nраз\nдва\n\nПрипев:\nля ля\n(х2)\n\n[Куплет 2]\nтри\n\n[Припев]\n\n2 раза",
+			want: []entities.LyricSection{
+				section(1, entities.SectionVerse, "Куплет 1", 1, 0, "раз", "два"),
+				section(2, entities.SectionChorus, "Припев", 2, 0, "ля ля"),
+				section(3, entities.SectionVerse, "Куплет 2", 1, 0, "три"),
+				section(4, entities.SectionChorus, "Припев", 2, 2, "ля ля"),
+			},
+		},
+		{
+			name: "unlabelled sections repeated word for word",
+			text: "one\ntwo\n\nla\nla\n\none\ntwo",
+			want: []entities.LyricSection{
+				section(1, entities.SectionVerse, "", 1, 0, "one", "two"),
+				section(2, entities.SectionVerse, "", 1, 0, "la", "la"),
+				section(3, entities.SectionVerse, "", 1, 1, "one", "two"),
+			},
+		},
+		{
+			name: "ad-libs in parentheses stay lyrics",
+			text: "(yeah)\nline",
+			want: []entities.LyricSection{
+				section(1, entities.SectionVerse, "", 1, 0, "(yeah)", "line"),
+			},
+		},
+		{
+			name: "unknown bracketed label",
+			text: "[Spoken]\nhi\n\r\n[spoken]",
+			want: []entities.LyricSection{
+				section(1, entities.SectionOther, "Spoken", 1, 0, "hi"),
+				section(2, entities.SectionOther, "spoken", 1, 1, "hi"),
+			},
+		},
+		{
+			name: "empty text",
+			text: "",
+			want: []entities.LyricSection{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseSections(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSections(%q)\n got %+v\nwant %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandRepeats(t *testing.T) {
+	got := ExpandRepeats(ParseSections("[Verse]\na\n\n[Chorus x2]\nla\n\n[Chorus]\n\n(x2)"))
+	want := []entities.LyricSection{
+		section(1, entities.SectionVerse, "Verse", 1, 0, "a"),
+		section(2, entities.SectionChorus, "Chorus", 1, 0, "la"),
+		section(3, entities.SectionChorus, "Chorus", 1, 2, "la"),
+		section(4, entities.SectionChorus, "Chorus", 1, 2, "la"),
+		section(5, entities.SectionChorus, "Chorus", 1, 2, "la"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandRepeats\n got %+v\nwant %+v", got, want)
+	}
+}
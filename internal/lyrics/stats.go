@@ -0,0 +1,191 @@
+package lyrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/senyabanana/library-service/internal/entities"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	wordsPerMinute = 200
+	topWordsLimit  = 10
+	repeatedLimit  = 10
+)
+
+var stopwords = map[string]map[string]bool{
+	"en": wordSet(`a about all am an and are as at be been but by can do don't for from get got had has have he her
+		him his how i i'm if in into is it it's just know like me my no not now of oh on one or our out she so that
+		the their them then there they this to too up us was we were what when where who will with would yeah you
+		you're your`),
+	"ru": wordSet(`а без более бы был была были было быть в вам вас весь во вот все всё всего всех вы где да даже для
+		до его ее её если есть еще ещё же за здесь и из или им их к как ко когда кто ли либо мне меня мы на над не
+		нет ни но ну о об однако он она они оно от по под при с со так также такой там те тебе тебя то того тоже
+		той только том ты у уж уже хоть чего чей чем что чтобы эта эти это я`),
+	"de": wordSet(`aber als am an auch auf aus bei bin bis bist da das dass dein deine dem den der des dich die dir
+		du ein eine einem einen einer es für hat hier ich ihr im in ist ja kein mein meine mich mir mit nicht noch
+		nur oder sie sind so und uns von vor war was wie wir zu`),
+	"es": wordSet(`a al como con de del el ella en era es esa ese eso esta este estoy fue ha la las le lo los me mi
+		mis muy más nada ni no nos o para pero por que qué se si sin sobre soy su sus te tu tú un una y ya yo`),
+	"fr": wordSet(`à au aux avec ce ces c'est dans de des du elle en est et il je j'ai la le les leur lui ma mais me
+		mes moi mon ne nous on ou où par pas pour qu'il que qui sa se ses si son sur ta te tes toi ton tu un une
+		vous y`),
+}
+
+// Analyze computes statistics of lyrics in the given language. When the
+// language is unknown or has no stopword list it is guessed from the
+// script. The second result counts the words that are not stopwords.
+func Analyze(text, lang string) (entities.LyricsStats, map[string]int) {
+	lines := lyricLines(text)
+	lang = statsLanguage(lang, lines)
+	stop := stopwords[lang]
+
+	stats := entities.LyricsStats{
+		Language:      lang,
+		Lines:         len(lines),
+		Verses:        len(ParseSections(text)),
+		TopWords:      []entities.WordCount{},
+		RepeatedLines: []entities.RepeatedLine{},
+	}
+	seen := map[string]bool{}
+	counts := map[string]int{}
+	lineCounts := map[string]*entities.RepeatedLine{}
+	var lineOrder []string
+
+	for _, line := range lines {
+		for _, word := range Words(line) {
+			stats.Words++
+			seen[word] = true
+			if !stop[word] {
+				counts[word]++
+			}
+		}
+
+		key := strings.ToLower(strings.TrimFunc(line, func(r rune) bool {
+			return unicode.IsPunct(r) || unicode.IsSpace(r)
+		}))
+		if key == "" {
+			continue
+		}
+		if repeated, ok := lineCounts[key]; ok {
+			repeated.Count++
+			continue
+		}
+		lineCounts[key] = &entities.RepeatedLine{Text: line, Count: 1}
+		lineOrder = append(lineOrder, key)
+	}
+
+	stats.UniqueWords = len(seen)
+	if stats.Words > 0 {
+		stats.UniqueWordRatio = math.Round(float64(stats.UniqueWords)/float64(stats.Words)*1000) / 1000
+	}
+	stats.ReadingTimeSeconds = ReadingTime(stats.Words)
+	stats.TopWords = TopWords(counts, topWordsLimit)
+
+	for _, key := range lineOrder {
+		if repeated := lineCounts[key]; repeated.Count > 1 {
+			stats.RepeatedLines = append(stats.RepeatedLines, *repeated)
+		}
+	}
+	sort.SliceStable(stats.RepeatedLines, func(i, j int) bool {
+		return stats.RepeatedLines[i].Count > stats.RepeatedLines[j].Count
+	})
+	if len(stats.RepeatedLines) > repeatedLimit {
+		stats.RepeatedLines = stats.RepeatedLines[:repeatedLimit]
+	}
+	return stats, counts
+}
+
+// Words splits a line into lowercase words. Apostrophes and hyphens inside a
+// word are kept.
+func Words(line string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’' && r != '-'
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		field = strings.ReplaceAll(strings.Trim(field, "'’-"), "’", "'")
+		if field != "" {
+			words = append(words, field)
+		}
+	}
+	return words
+}
+
+// ReadingTime estimates in seconds how long the words take to read.
+func ReadingTime(words int) int {
+	return int(math.Ceil(float64(words) * 60 / wordsPerMinute))
+}
+
+// TopWords returns the limit most frequent words, ties broken
+// alphabetically.
+func TopWords(counts map[string]int, limit int) []entities.WordCount {
+	top := make([]entities.WordCount, 0, len(counts))
+	for word, count := range counts {
+		top = append(top, entities.WordCount{Word: word, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Word < top[j].Word
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top
+}
+
+// lyricLines returns the non-empty lines of the lyrics without section
+// labels and repeat markers.
+func lyricLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(NormalizeText(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || repeatLine.MatchString(line) {
+			continue
+		}
+		if _, ok := parseHeader(line); ok {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func statsLanguage(lang string, lines []string) string {
+	if tag, err := language.Parse(lang); err == nil {
+		base, confidence := tag.Base()
+		if _, ok := stopwords[base.String()]; ok && confidence == language.Exact {
+			return base.String()
+		}
+	}
+
+	var cyrillic, latin int
+	for _, line := range lines {
+		for _, r := range line {
+			switch {
+			case unicode.Is(unicode.Cyrillic, r):
+				cyrillic++
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			}
+		}
+	}
+	if cyrillic > latin {
+		return "ru"
+	}
+	return "en"
+}
+
+func wordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+const apiKeyScheme = "ApiKey"
+
+type AuthMiddleware struct {
+	service services.APIKeyServiceInterface
+	logg    *logger.Logger
+}
+
+func NewAuthMiddleware(service services.APIKeyServiceInterface, logg *logger.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// Authenticate resolves the Authorization header into a principal stored in
+// the request context. Requests without credentials pass through anonymously;
+// routes that need a principal are wrapped with RequireScope.
+func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, credentials, _ := strings.Cut(header, " ")
+		credentials = strings.TrimSpace(credentials)
+		if !strings.EqualFold(scheme, apiKeyScheme) || credentials == "" {
+			m.logg.WithField("scheme", scheme).Warn("Unsupported authorization scheme")
+			unauthorized(w, "Unsupported authorization scheme")
+			return
+		}
+
+		principal, err := m.service.Authenticate(r.Context(), credentials)
+		if err != nil {
+			if errors.Is(err, services.ErrUnauthorized) {
+				m.logg.WithError(err).Warn("API key rejected")
+				unauthorized(w, err.Error())
+				return
+			}
+			m.logg.WithError(err).Error("Failed to authenticate API key")
+			http.Error(w, "Failed to authenticate", http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func (m *AuthMiddleware) RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			unauthorized(w, "Authentication required")
+			return
+		}
+		if !principal.HasScope(scope) {
+			m.logg.WithField("key", principal.Name).Warnf("API key lacks %q scope", scope)
+			http.Error(w, "API key lacks required scope: "+scope, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", apiKeyScheme)
+	http.Error(w, message, http.StatusUnauthorized)
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the request bodies buffered to fingerprint
+	// them; larger uploads must be sent without an Idempotency-Key.
+	maxIdempotentBodySize = 32 << 20
+)
+
+// replayedHeaders are the response headers stored with an idempotent
+// response and sent again on replay.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+type IdempotencyMiddleware struct {
+	service services.IdempotencyServiceInterface
+	logg    *logger.Logger
+}
+
+func NewIdempotencyMiddleware(service services.IdempotencyServiceInterface, logg *logger.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// Idempotent stores the response of write requests carrying an
+// Idempotency-Key and replays it for retries with the same key and request.
+// Reusing a key for a different request is rejected with 422. Responses with
+// a 5xx status are not stored, so such requests can be retried.
+func (m *IdempotencyMiddleware) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || !isWriteMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			http.Error(w, "Request body is too large to be used with an Idempotency-Key", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := m.service.Begin(r.Context(), key, fingerprint(r, body))
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, "Failed to check idempotency key", http.StatusInternalServerError)
+			return
+		}
+
+		if record != nil {
+			for name, values := range record.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Body)
+			return
+		}
+
+		// The client may have given up on the request, which is exactly when
+		// it will retry, so the outcome is stored even if ctx is cancelled.
+		storeCtx := context.WithoutCancel(r.Context())
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if p := recover(); p != nil {
+				m.service.Release(storeCtx, key)
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= http.StatusInternalServerError {
+			m.service.Release(storeCtx, key)
+			return
+		}
+		header := map[string][]string{}
+		for _, name := range replayedHeaders {
+			if values := w.Header().Values(name); len(values) > 0 {
+				header[name] = values
+			}
+		}
+		m.service.Complete(storeCtx, key, rec.status, header, rec.body.Bytes())
+	})
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// fingerprint identifies a request by method, target and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/ratelimit"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RateLimitMiddleware struct {
+	limiter *ratelimit.Limiter
+	policy  ratelimit.Policy
+	// trustProxy takes the client IP from the last X-Forwarded-For entry,
+	// the one the reverse proxy in front of the service appended.
+	trustProxy bool
+	logg       *logger.Logger
+}
+
+func NewRateLimitMiddleware(limiter *ratelimit.Limiter, policy ratelimit.Policy, trustProxy bool, logg *logger.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter:    limiter,
+		policy:     policy,
+		trustProxy: trustProxy,
+		logg:       logg,
+	}
+}
+
+// LimitAuth counts requests that carry credentials against the per-IP
+// authentication limit. It runs before authentication, so that requests
+// with unknown keys are limited too. A nil middleware does not limit.
+func (m *RateLimitMiddleware) LimitAuth(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := "ip:" + m.clientIP(r)
+		result, err := m.limiter.Take(r.Context(), client+":auth", m.policy.IPAuth)
+		if err != nil {
+			m.logg.WithError(err).Error("Failed to check rate limit")
+		} else if !result.Allowed {
+			m.reject(w, result, client, "auth", "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Limit counts each request against the read or write limit of its client
+// and, for API keys, against the daily quota. The tightest of them is
+// reported in RateLimit-* headers; requests over a limit get 429 with
+// Retry-After. If the store fails, requests are let through. A nil
+// middleware does not limit.
+func (m *RateLimitMiddleware) Limit(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/swagger/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client, rule, quota := m.classify(r)
+		bucket := "read"
+		if isWriteMethod(r.Method) {
+			bucket = "write"
+		}
+
+		result, err := m.limiter.Take(r.Context(), client+":"+bucket, rule)
+		if err != nil {
+			m.logg.WithError(err).Error("Failed to check rate limit")
+			next.ServeHTTP(w, r)
+			return
+		}
+		reason := "Rate limit exceeded"
+		if result.Allowed && quota > 0 {
+			daily, err := m.limiter.Take(r.Context(), client+":daily", ratelimit.Rule{Limit: quota, Window: 24 * time.Hour})
+			if err != nil {
+				m.logg.WithError(err).Error("Failed to check daily quota")
+			} else if !daily.Allowed || daily.Remaining < result.Remaining {
+				result, reason = daily, "Daily quota exceeded"
+			}
+		}
+
+		if !result.Allowed {
+			m.reject(w, result, client, bucket, reason)
+			return
+		}
+		setRateLimitHeaders(w, result)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *RateLimitMiddleware) reject(w http.ResponseWriter, result ratelimit.Result, client, bucket, reason string) {
+	m.logg.WithFields(logrus.Fields{
+		"client": client,
+		"bucket": bucket,
+		"limit":  result.Limit,
+	}).Warn(reason)
+	setRateLimitHeaders(w, result)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds(result.Reset)))
+	http.Error(w, reason, http.StatusTooManyRequests)
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+}
+
+// classify identifies the client of r and returns the rule for its bucket
+// and its daily quota.
+func (m *RateLimitMiddleware) classify(r *http.Request) (string, ratelimit.Rule, int) {
+	write := isWriteMethod(r.Method)
+
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		client := "key:" + strconv.Itoa(principal.KeyID)
+		if principal.KeyID == 0 {
+			client = "key:" + principal.Name
+		}
+		quota := m.policy.DailyQuota
+		if principal.DailyQuota != nil {
+			quota = *principal.DailyQuota
+		}
+		if write {
+			return client, m.policy.KeyWrite, quota
+		}
+		return client, m.policy.KeyRead, quota
+	}
+
+	client := "ip:" + m.clientIP(r)
+	if write {
+		return client, m.policy.IPWrite, 0
+	}
+	return client, m.policy.IPRead, 0
+}
+
+func (m *RateLimitMiddleware) clientIP(r *http.Request) string {
+	if m.trustProxy {
+		// Earlier entries come from the client and cannot be trusted.
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := net.ParseIP(strings.TrimSpace(entries[len(entries)-1])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
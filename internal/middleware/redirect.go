@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/services"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RedirectMiddleware struct {
+	service services.SongServiceInterface
+	logg    *logger.Logger
+}
+
+func NewRedirectMiddleware(service services.SongServiceInterface, logg *logger.Logger) *RedirectMiddleware {
+	return &RedirectMiddleware{
+		service: service,
+		logg:    logg,
+	}
+}
+
+// Redirect sends requests for /songs/{id}/... with the ID of a merged-away
+// song to the same path on the surviving song. 308 keeps the method and body,
+// so writes follow the redirect as well.
+func (m *RedirectMiddleware) Redirect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/songs/")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		idStr, tail, hasTail := strings.Cut(rest, "/")
+		id, err := strconv.Atoi(idStr)
+		if err != nil || id <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		target, found, err := m.service.ResolveSongID(r.Context(), id)
+		if err != nil {
+			m.logg.WithError(err).WithField("id", id).Error("Failed to resolve song redirect")
+			http.Error(w, "Failed to resolve song", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		location := "/songs/" + strconv.Itoa(target)
+		if hasTail {
+			location += "/" + tail
+		}
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+
+		m.logg.WithFields(logrus.Fields{
+			"id":       id,
+			"location": location,
+		}).Debug("Redirecting merged song")
+		http.Redirect(w, r, location, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/senyabanana/library-service/internal/requestid"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID propagates the caller's X-Request-ID, or generates one, and
+// echoes it back in the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithRequestID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,201 @@
+// Package mockinfo serves the /info contract of the external music API
+// from fixtures, with knobs for the failures a real upstream shows, so that
+// enrichment can be exercised offline and in tests.
+package mockinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/enrichment"
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+// Forced responses that can be requested per call with the X-Mock-Response
+// header, regardless of the configured rates.
+const (
+	ResponseOK        = "ok"
+	ResponseError     = "error"
+	ResponseNotFound  = "not_found"
+	ResponseMalformed = "malformed"
+)
+
+// ResponseHeader forces the outcome of a single request.
+const ResponseHeader = "X-Mock-Response"
+
+// Options configure the failures the handler injects. Rates are
+// probabilities between 0 and 1 and are checked in the order errors, 404s,
+// malformed JSON. Every request waits Latency plus a random part of Jitter.
+type Options struct {
+	Latency       time.Duration
+	Jitter        time.Duration
+	ErrorRate     float64
+	NotFoundRate  float64
+	MalformedRate float64
+	// Seed makes the injected failures reproducible; zero seeds from the
+	// clock.
+	Seed int64
+}
+
+func (o Options) validate() error {
+	for name, rate := range map[string]float64{"error": o.ErrorRate, "not found": o.NotFoundRate, "malformed": o.MalformedRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s rate %v is outside [0, 1]", name, rate)
+		}
+	}
+	if o.Latency < 0 || o.Jitter < 0 {
+		return fmt.Errorf("latency and jitter must not be negative")
+	}
+	return nil
+}
+
+// Handler answers GET /info?group=&song= from its entries.
+type Handler struct {
+	songs map[string]entities.Details
+	opts  Options
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func New(entries []enrichment.Entry, opts Options) (*Handler, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	h := &Handler{
+		songs: make(map[string]entities.Details, len(entries)),
+		opts:  opts,
+		rnd:   rand.New(rand.NewSource(seed)),
+	}
+	for _, entry := range entries {
+		h.songs[key(entry.Group, entry.Song)] = entry.Details
+	}
+	return h, nil
+}
+
+// NewServer starts an httptest server with the handler; close it when done.
+func NewServer(entries []enrichment.Entry, opts Options) (*httptest.Server, error) {
+	h, err := New(entries, opts)
+	if err != nil {
+		return nil, err
+	}
+	return httptest.NewServer(h), nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/info" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	group, song := r.URL.Query().Get("group"), r.URL.Query().Get("song")
+	if strings.TrimSpace(group) == "" || strings.TrimSpace(song) == "" {
+		http.Error(w, "group and song are required", http.StatusBadRequest)
+		return
+	}
+
+	response, delay := h.roll(r.Header.Get(ResponseHeader))
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	details, ok := h.songs[key(group, song)]
+	switch {
+	case response == ResponseError:
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	case response == ResponseNotFound || !ok:
+		http.Error(w, "Not Found", http.StatusNotFound)
+	case response == ResponseMalformed:
+		w.Header().Set("Content-Type", "application/json")
+		body, _ := json.Marshal(details)
+		w.Write(body[:len(body)/2])
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(details)
+	}
+}
+
+// roll picks the outcome and delay of a request. A forced response skips
+// the rates but not the latency.
+func (h *Handler) roll(forced string) (string, time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delay := h.opts.Latency
+	if h.opts.Jitter > 0 {
+		delay += time.Duration(h.rnd.Int63n(int64(h.opts.Jitter)))
+	}
+	switch forced {
+	case ResponseOK, ResponseError, ResponseNotFound, ResponseMalformed:
+		return forced, delay
+	}
+
+	switch p := h.rnd.Float64(); {
+	case p < h.opts.ErrorRate:
+		return ResponseError, delay
+	case p < h.opts.ErrorRate+h.opts.NotFoundRate:
+		return ResponseNotFound, delay
+	case p < h.opts.ErrorRate+h.opts.NotFoundRate+h.opts.MalformedRate:
+		return ResponseMalformed, delay
+	}
+	return ResponseOK, delay
+}
+
+// LoadFixtures reads entries from a JSON file, or from every .json file in
+// a directory. An empty path gives the fixtures bundled with the enrichment
+// package.
+func LoadFixtures(path string) ([]enrichment.Entry, error) {
+	if path == "" {
+		return enrichment.FixtureEntries(), nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = filepath.Glob(filepath.Join(path, "*.json")); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []enrichment.Entry
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := enrichment.ParseEntries(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		entries = append(entries, parsed...)
+	}
+	return entries, nil
+}
+
+func key(group, song string) string {
+	return strings.ToLower(strings.TrimSpace(group)) + "\x00" + strings.ToLower(strings.TrimSpace(song))
+}
@@ -0,0 +1,212 @@
+package mockinfo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/api"
+	"github.com/senyabanana/library-service/internal/enrichment"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/mockinfo"
+)
+
+var starlight = enrichment.Entry{
+	Group: "Muse",
+	Song:  "Starlight",
+	Details: entities.Details{
+		ReleaseDate: "04.09.2006",
+		Text:        "Fixture lyrics for Starlight",
+		Link:        "https://open.spotify.com/track/3skn2lauGk7Dx6bVIt5DVj",
+	},
+}
+
+func newClient(t *testing.T, opts mockinfo.Options) *api.MusicAPIClient {
+	t.Helper()
+	server, err := mockinfo.NewServer([]enrichment.Entry{starlight}, opts)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(server.Close)
+	return api.NewMusicAPIClient(server.URL, nil, logger.NewLogger())
+}
+
+func TestGetDetails(t *testing.T) {
+	client := newClient(t, mockinfo.Options{})
+
+	details, err := client.GetDetails(context.Background(), "muse", "STARLIGHT")
+	if err != nil {
+		t.Fatalf("GetDetails: %v", err)
+	}
+	if details != starlight.Details {
+		t.Errorf("details = %+v, want %+v", details, starlight.Details)
+	}
+
+	if _, err := client.GetDetails(context.Background(), "Muse", "Unknown"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("unknown song: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetDetailsFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     mockinfo.Options
+		notFound bool
+	}{
+		{"server error", mockinfo.Options{ErrorRate: 1}, false},
+		{"not found", mockinfo.Options{NotFoundRate: 1}, true},
+		{"malformed json", mockinfo.Options{MalformedRate: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newClient(t, tt.opts)
+
+			_, err := client.GetDetails(context.Background(), starlight.Group, starlight.Song)
+			if err == nil {
+				t.Fatal("GetDetails succeeded, want an error")
+			}
+			if errors.Is(err, api.ErrNotFound) != tt.notFound {
+				t.Errorf("err = %v, ErrNotFound expected: %v", err, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestGetDetailsLatency(t *testing.T) {
+	client := newClient(t, mockinfo.Options{Latency: 100 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := client.GetDetails(context.Background(), starlight.Group, starlight.Song); err != nil {
+		t.Fatalf("GetDetails: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("answered after %v, want at least the latency", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetDetails(ctx, starlight.Group, starlight.Song); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPipelineFallsBackOnFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     mockinfo.Options
+		provider string
+	}{
+		{"healthy api", mockinfo.Options{}, enrichment.ProviderAPI},
+		{"not found", mockinfo.Options{NotFoundRate: 1}, enrichment.ProviderPlaceholder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logg := logger.NewLogger()
+			pipeline := enrichment.NewPipeline([]enrichment.Enricher{
+				enrichment.NewAPIProvider(newClient(t, tt.opts)),
+				enrichment.Placeholder{},
+			}, nil, logg)
+
+			result, err := pipeline.Enrich(context.Background(), starlight.Group, starlight.Song)
+			if err != nil {
+				t.Fatalf("Enrich: %v", err)
+			}
+			if len(result.Sources) != len(enrichment.Fields) {
+				t.Fatalf("sources = %+v, want one per field", result.Sources)
+			}
+			for _, source := range result.Sources {
+				if source.Provider != tt.provider {
+					t.Errorf("%s came from %q, want %q", source.Field, source.Provider, tt.provider)
+				}
+			}
+		})
+	}
+}
+
+// An upstream failure is reported even when the placeholder could fill the
+// song, so that a refresh does not count it as a success.
+func TestPipelineReportsUpstreamError(t *testing.T) {
+	tests := []struct {
+		name string
+		opts mockinfo.Options
+	}{
+		{"server error", mockinfo.Options{ErrorRate: 1}},
+		{"malformed json", mockinfo.Options{MalformedRate: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, enrichers := range [][]enrichment.Enricher{
+				{enrichment.NewAPIProvider(newClient(t, tt.opts))},
+				{enrichment.NewAPIProvider(newClient(t, tt.opts)), enrichment.Placeholder{}},
+			} {
+				pipeline := enrichment.NewPipeline(enrichers, nil, logger.NewLogger())
+				_, err := pipeline.Enrich(context.Background(), starlight.Group, starlight.Song)
+				if err == nil || errors.Is(err, enrichment.ErrNotFound) {
+					t.Errorf("with %d providers: err = %v, want the upstream error", len(enrichers), err)
+				}
+			}
+		})
+	}
+}
+
+func TestForcedResponse(t *testing.T) {
+	handler, err := mockinfo.New([]enrichment.Entry{starlight}, mockinfo.Options{ErrorRate: 1})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := map[string]int{
+		mockinfo.ResponseOK:        http.StatusOK,
+		mockinfo.ResponseError:     http.StatusInternalServerError,
+		mockinfo.ResponseNotFound:  http.StatusNotFound,
+		mockinfo.ResponseMalformed: http.StatusOK,
+	}
+	for response, status := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/info?group=Muse&song=Starlight", nil)
+		req.Header.Set(mockinfo.ResponseHeader, response)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Errorf("%s: status = %d, want %d", response, rec.Code, status)
+		}
+	}
+}
+
+func TestSeedIsReproducible(t *testing.T) {
+	statuses := func() []int {
+		handler, err := mockinfo.New([]enrichment.Entry{starlight}, mockinfo.Options{ErrorRate: 0.5, Seed: 42})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		var got []int
+		for range 20 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/info?group=Muse&song=Starlight", nil))
+			got = append(got, rec.Code)
+		}
+		return got
+	}
+
+	first, second := statuses(), statuses()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("run differs at request %d: %v vs %v", i, first, second)
+		}
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	if _, err := mockinfo.New(nil, mockinfo.Options{ErrorRate: 1.5}); err == nil {
+		t.Error("New accepted an error rate above 1")
+	}
+	if _, err := mockinfo.New(nil, mockinfo.Options{Latency: -time.Second}); err == nil {
+		t.Error("New accepted a negative latency")
+	}
+}
@@ -0,0 +1,170 @@
+// Package profanity flags and masks explicit words in lyrics.
+package profanity
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed dictionary.json
+var defaultDictionary []byte
+
+// Dictionary holds explicit words per language. An entry ending in "*"
+// matches every word that starts with it.
+type Dictionary struct {
+	words    map[string]map[string]bool
+	prefixes map[string][]string
+}
+
+// Default returns the dictionary bundled with the service.
+func Default() *Dictionary {
+	d, err := Parse(defaultDictionary)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Load reads a dictionary from a JSON file that maps language codes to
+// lists of words. An empty path loads the bundled dictionary.
+func Load(path string) (*Dictionary, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (*Dictionary, error) {
+	var entries map[string][]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid profanity dictionary: %w", err)
+	}
+
+	d := &Dictionary{words: map[string]map[string]bool{}, prefixes: map[string][]string{}}
+	for lang, words := range entries {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			return nil, fmt.Errorf("invalid profanity dictionary language %q", lang)
+		}
+		base, _ := tag.Base()
+		lang = base.String()
+		if d.words[lang] == nil {
+			d.words[lang] = map[string]bool{}
+		}
+		for _, word := range words {
+			word = normalize(strings.TrimSpace(word))
+			if prefix, ok := strings.CutSuffix(word, "*"); ok && prefix != "" {
+				d.prefixes[lang] = append(d.prefixes[lang], prefix)
+			} else if word != "" {
+				d.words[lang][word] = true
+			}
+		}
+	}
+	return d, nil
+}
+
+// Explicit reports whether text contains a word from the dictionary of lang.
+// Words of all languages are checked when lang is unknown or has no
+// dictionary.
+func (d *Dictionary) Explicit(text, lang string) bool {
+	langs := d.languages(lang)
+	explicit := false
+	eachWord(text, func(start, end int) bool {
+		explicit = d.match(text[start:end], langs)
+		return !explicit
+	})
+	return explicit
+}
+
+// Mask replaces every letter of explicit words but the first with "*".
+func (d *Dictionary) Mask(text, lang string) string {
+	langs := d.languages(lang)
+	var b strings.Builder
+	last := 0
+	eachWord(text, func(start, end int) bool {
+		word := text[start:end]
+		if !d.match(word, langs) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(word)
+		b.WriteString(text[last : start+size])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(word[size:])))
+		last = end
+		return true
+	})
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func (d *Dictionary) languages(lang string) []string {
+	if tag, err := language.Parse(lang); err == nil {
+		if base, confidence := tag.Base(); confidence == language.Exact {
+			if _, ok := d.words[base.String()]; ok {
+				return []string{base.String()}
+			}
+		}
+	}
+	langs := make([]string, 0, len(d.words))
+	for lang := range d.words {
+		langs = append(langs, lang)
+	}
+	return langs
+}
+
+func (d *Dictionary) match(word string, langs []string) bool {
+	word = normalize(word)
+	for _, lang := range langs {
+		if d.words[lang][word] {
+			return true
+		}
+		for _, prefix := range d.prefixes[lang] {
+			if strings.HasPrefix(word, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// eachWord calls fn with the byte offsets of every word in text until fn
+// returns false. Apostrophes inside a word belong to it; hyphens split
+// words, so parts of compounds are checked on their own.
+func eachWord(text string, fn func(start, end int) bool) {
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || (start >= 0 && (r == '\'' || r == '’'))
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord && start >= 0 {
+			if !fn(start, trimApostrophes(text, start, i)) {
+				return
+			}
+			start = -1
+		}
+	}
+	if start >= 0 {
+		fn(start, trimApostrophes(text, start, len(text)))
+	}
+}
+
+func trimApostrophes(text string, start, end int) int {
+	return start + len(strings.TrimRight(text[start:end], "'’"))
+}
+
+func normalize(word string) string {
+	return strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process memory. Each instance of the
+// service counts on its own.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+type counter struct {
+	count     int
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, expiresAt time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok {
+		c = &counter{expiresAt: expiresAt}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, nil
+}
+
+func (s *MemoryStore) DeleteExpired(_ context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now, deleted := time.Now(), 0
+	for key, c := range s.counters {
+		if !c.expiresAt.After(now) {
+			delete(s.counters, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
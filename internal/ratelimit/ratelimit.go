@@ -0,0 +1,75 @@
+// Package ratelimit counts requests per client in fixed windows.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Store keeps request counters. Keys are unique per window, so a counter
+// is never reset, only dropped once it expires.
+type Store interface {
+	// Increment adds one to the counter under key, creating it with the
+	// given expiry, and returns the new count.
+	Increment(ctx context.Context, key string, expiresAt time.Time) (int, error)
+	DeleteExpired(ctx context.Context) (int, error)
+}
+
+// Rule allows Limit requests per Window. Windows are aligned to the clock,
+// so a 24h window is a UTC day.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Result is the state of a counter after a request was counted.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the window ends.
+	Reset time.Duration
+}
+
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Take counts a request of the client identified by key against rule.
+func (l *Limiter) Take(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := time.Now()
+	start := now.Truncate(rule.Window)
+	end := start.Add(rule.Window)
+
+	count, err := l.store.Increment(ctx, fmt.Sprintf("%s:%d", key, start.Unix()), end)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:   count <= rule.Limit,
+		Limit:     rule.Limit,
+		Remaining: max(rule.Limit-count, 0),
+		Reset:     end.Sub(now),
+	}, nil
+}
+
+// Policy holds the limits enforced on inbound requests. Anonymous clients
+// are limited per IP and authenticated ones per API key, with separate
+// rules for reads and writes. Requests carrying an API key are also limited
+// per IP by IPAuth before the key is looked up, so that invalid keys cannot
+// be tried without limit.
+type Policy struct {
+	IPRead   Rule
+	IPWrite  Rule
+	IPAuth   Rule
+	KeyRead  Rule
+	KeyWrite Rule
+	// DailyQuota is the default number of requests an API key may make per
+	// UTC day; 0 means no quota.
+	DailyQuota int
+}
@@ -0,0 +1,90 @@
+// Package releasedate parses release dates that may be known only to the
+// year or month.
+package releasedate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	PrecisionYear  = "year"
+	PrecisionMonth = "month"
+	PrecisionDay   = "day"
+)
+
+// ErrInvalid is returned for values in none of the accepted formats.
+var ErrInvalid = errors.New("expected YYYY-MM-DD, YYYY-MM, YYYY, DD.MM.YYYY, MM.YYYY or a date such as \"16 July 2006\"")
+
+var layouts = []struct {
+	layout    string
+	precision string
+}{
+	{"2006-1-2", PrecisionDay},
+	{"2.1.2006", PrecisionDay},
+	{"2006/1/2", PrecisionDay},
+	{"2006.1.2", PrecisionDay},
+	{time.RFC3339, PrecisionDay},
+	{"January 2, 2006", PrecisionDay},
+	{"Jan 2, 2006", PrecisionDay},
+	{"2 January 2006", PrecisionDay},
+	{"2 Jan 2006", PrecisionDay},
+	{"2006-1", PrecisionMonth},
+	{"1.2006", PrecisionMonth},
+	{"2006/1", PrecisionMonth},
+	{"January 2006", PrecisionMonth},
+	{"Jan 2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// Date is a release date known to Precision. Time is the first day of the
+// year or month when the day or month is unknown.
+type Date struct {
+	Time      time.Time
+	Precision string
+}
+
+func Parse(value string) (Date, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Date{}, fmt.Errorf("release date is empty: %w", ErrInvalid)
+	}
+	for _, l := range layouts {
+		t, err := time.Parse(l.layout, value)
+		if err != nil {
+			continue
+		}
+		if t.Year() < 1000 {
+			break
+		}
+		return Date{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), Precision: l.precision}, nil
+	}
+	return Date{}, fmt.Errorf("invalid release date %q: %w", value, ErrInvalid)
+}
+
+// String formats the date in ISO 8601 to its precision: 2006, 2006-07 or
+// 2006-07-16.
+func (d Date) String() string {
+	return Format(d.Time, d.Precision)
+}
+
+func Format(t time.Time, precision string) string {
+	switch precision {
+	case PrecisionYear:
+		return t.Format("2006")
+	case PrecisionMonth:
+		return t.Format("2006-01")
+	}
+	return t.Format(time.DateOnly)
+}
+
+// Normalize returns value in ISO 8601 to the precision it was given in.
+func Normalize(value string) (string, error) {
+	d, err := Parse(value)
+	if err != nil {
+		return "", err
+	}
+	return d.String(), nil
+}
@@ -0,0 +1,50 @@
+package releasedate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      string
+		precision string
+	}{
+		{"2006-07-16", "2006-07-16", PrecisionDay},
+		{"2006-7-6", "2006-07-06", PrecisionDay},
+		{"16.07.2006", "2006-07-16", PrecisionDay},
+		{"2006/07/16", "2006-07-16", PrecisionDay},
+		{"2006-07-16T10:00:00Z", "2006-07-16", PrecisionDay},
+		{"16 July 2006", "2006-07-16", PrecisionDay},
+		{"Jul 16, 2006", "2006-07-16", PrecisionDay},
+		{"2006-07", "2006-07", PrecisionMonth},
+		{"07.2006", "2006-07", PrecisionMonth},
+		{"July 2006", "2006-07", PrecisionMonth},
+		{"2006", "2006", PrecisionYear},
+		{" 2006 ", "2006", PrecisionYear},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			d, err := Parse(tt.value)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.value, err)
+			}
+			if d.String() != tt.want || d.Precision != tt.precision {
+				t.Errorf("Parse(%q) = %s (%s), want %s (%s)", tt.value, d, d.Precision, tt.want, tt.precision)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "   ", "not a date", "2006-13", "31.02.2006", "0999", "16/07/2006"} {
+		t.Run(value, func(t *testing.T) {
+			d, err := Parse(value)
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Parse(%q) = %v, %v, want ErrInvalid", value, d, err)
+			}
+		})
+	}
+}
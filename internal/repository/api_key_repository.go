@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyRepositoryInterface interface {
+	CreateAPIKey(ctx context.Context, key entities.APIKey, hash string) (entities.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	GetAPIKey(ctx context.Context, id int) (entities.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) (bool, error)
+	SetAPIKeyRole(ctx context.Context, id int, role string) (entities.APIKey, error)
+	SetAPIKeyQuota(ctx context.Context, id int, quota *int) (entities.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+type APIKeyRepository struct {
+	db   *sql.DB
+	logg *logger.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, logg *logger.Logger) *APIKeyRepository {
+	return &APIKeyRepository{
+		db:   db,
+		logg: logg,
+	}
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, role, daily_quota, created_at, last_used_at, expires_at, revoked_at`
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key entities.APIKey, hash string) (entities.APIKey, error) {
+	query := `INSERT INTO api_keys (name, prefix, key_hash, scopes, role, daily_quota, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING ` + apiKeyColumns
+	r.logg.WithField("query", query).Debug("Executing query to create API key")
+
+	row := r.db.QueryRowContext(ctx, query, key.Name, key.Prefix, hash, pq.Array(key.Scopes), key.Role, key.DailyQuota, key.ExpiresAt)
+	created, err := scanAPIKey(row)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute CreateAPIKey query")
+		return entities.APIKey{}, err
+	}
+
+	r.logg.WithField("key_id", created.ID).Info("API key created successfully")
+	return created, nil
+}
+
+func (r *APIKeyRepository) GetAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+	r.logg.WithField("query", query).Debug("Executing query to fetch API keys")
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetAPIKeys query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entities.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetAPIKeys")
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		r.logg.WithError(err).Error("Failed to iterate rows in GetAPIKeys")
+		return nil, err
+	}
+
+	r.logg.WithField("count", len(keys)).Info("Fetched API keys successfully")
+	return keys, nil
+}
+
+func (r *APIKeyRepository) GetAPIKey(ctx context.Context, id int) (entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	r.logg.WithField("query", query).Debug("Executing query to fetch API key")
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetAPIKey query")
+		}
+		return entities.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (entities.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetAPIKeyByHash query")
+		}
+		return entities.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id int) (bool, error) {
+	query := `UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`
+	r.logg.WithField("query", query).Debug("Executing query to revoke API key")
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute RevokeAPIKey query")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	r.logg.WithField("key_id", id).Info("API key revoked successfully")
+	return affected > 0, nil
+}
+
+func (r *APIKeyRepository) SetAPIKeyRole(ctx context.Context, id int, role string) (entities.APIKey, error) {
+	query := `UPDATE api_keys SET role = $1 WHERE id = $2 RETURNING ` + apiKeyColumns
+	r.logg.WithField("query", query).Debug("Executing query to assign API key role")
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, role, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute SetAPIKeyRole query")
+		}
+		return entities.APIKey{}, err
+	}
+
+	r.logg.WithFields(logrus.Fields{
+		"key_id": id,
+		"role":   role,
+	}).Info("API key role assigned successfully")
+	return key, nil
+}
+
+func (r *APIKeyRepository) SetAPIKeyQuota(ctx context.Context, id int, quota *int) (entities.APIKey, error) {
+	query := `UPDATE api_keys SET daily_quota = $1 WHERE id = $2 RETURNING ` + apiKeyColumns
+	r.logg.WithField("query", query).Debug("Executing query to set API key quota")
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, quota, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute SetAPIKeyQuota query")
+		}
+		return entities.APIKey{}, err
+	}
+
+	r.logg.WithField("key_id", id).Info("API key quota set successfully")
+	return key, nil
+}
+
+// TouchAPIKey bumps last_used_at, at most once a minute per key, so that
+// authenticating every request does not turn into a write per request.
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		r.logg.WithError(err).Error("Failed to execute TouchAPIKey query")
+		return err
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAPIKey(row rowScanner) (entities.APIKey, error) {
+	var key entities.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.Role, &key.DailyQuota, &key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt)
+	return key, err
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/requestid"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type AuditRepositoryInterface interface {
+	RecordEvent(ctx context.Context, q DBTX, action, entityType string, entityID int, before, after interface{}) error
+	GetEventsWithQuery(ctx context.Context, query string, args ...interface{}) ([]entities.AuditEvent, error)
+}
+
+type AuditRepository struct {
+	db   *sql.DB
+	logg *logger.Logger
+}
+
+func NewAuditRepository(db *sql.DB, logg *logger.Logger) *AuditRepository {
+	return &AuditRepository{
+		db:   db,
+		logg: logg,
+	}
+}
+
+// RecordEvent appends an audit event through q, so callers can write it in
+// the same transaction as the change it describes. The actor and request ID
+// are taken from ctx; before and after may be nil for creates and deletes.
+func (r *AuditRepository) RecordEvent(ctx context.Context, q DBTX, action, entityType string, entityID int, before, after interface{}) error {
+	event := entities.AuditEvent{
+		Actor:      auth.ActorName(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		RequestID:  requestid.FromContext(ctx),
+	}
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.KeyID != 0 {
+		event.ActorKeyID = &principal.KeyID
+	}
+
+	var err error
+	if event.Before, err = marshalAuditState(before); err != nil {
+		return err
+	}
+	if event.After, err = marshalAuditState(after); err != nil {
+		return err
+	}
+	if event.Diff, err = diffAuditStates(event.Before, event.After); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO audit_events (actor, actor_key_id, action, entity_type, entity_id, before, after, diff, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	r.logg.WithFields(logrus.Fields{
+		"action":    action,
+		"entity":    entityType,
+		"entity_id": entityID,
+	}).Debug("Recording audit event")
+
+	_, err = q.ExecContext(ctx, query, event.Actor, event.ActorKeyID, event.Action, event.EntityType, event.EntityID,
+		nullableJSON(event.Before), nullableJSON(event.After), nullableJSON(event.Diff), event.RequestID)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute RecordEvent query")
+		return err
+	}
+	return nil
+}
+
+func (r *AuditRepository) GetEventsWithQuery(ctx context.Context, query string, args ...interface{}) ([]entities.AuditEvent, error) {
+	r.logg.WithField("query", query).Debug("Executing audit query with filters")
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetEventsWithQuery")
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []entities.AuditEvent{}
+	for rows.Next() {
+		var event entities.AuditEvent
+		if err := rows.Scan(&event.ID, &event.Actor, &event.ActorKeyID, &event.Action, &event.EntityType, &event.EntityID,
+			&event.Before, &event.After, &event.Diff, &event.RequestID, &event.CreatedAt); err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetEventsWithQuery")
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		r.logg.WithError(err).Error("Failed to iterate rows in GetEventsWithQuery")
+		return nil, err
+	}
+
+	r.logg.WithField("count", len(events)).Info("Fetched audit events successfully")
+	return events, nil
+}
+
+func marshalAuditState(state interface{}) (json.RawMessage, error) {
+	if state == nil || reflect.ValueOf(state).Kind() == reflect.Ptr && reflect.ValueOf(state).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+// diffAuditStates returns the top-level fields that differ between two JSON
+// objects as {"field": {"from": ..., "to": ...}}.
+func diffAuditStates(before, after json.RawMessage) (json.RawMessage, error) {
+	type change struct {
+		From interface{} `json:"from"`
+		To   interface{} `json:"to"`
+	}
+
+	var from, to map[string]interface{}
+	if before != nil {
+		if err := json.Unmarshal(before, &from); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &to); err != nil {
+			return nil, err
+		}
+	}
+
+	diff := map[string]change{}
+	for field, value := range from {
+		if next, ok := to[field]; !ok || !reflect.DeepEqual(value, next) {
+			diff[field] = change{From: value, To: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			diff[field] = change{To: value}
+		}
+	}
+	if len(diff) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(diff)
+}
+
+func nullableJSON(data json.RawMessage) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
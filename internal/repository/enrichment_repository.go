@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EnrichmentRepositoryInterface interface {
+	GetFieldSources(ctx context.Context, songID int) ([]entities.FieldSource, error)
+	SaveFieldSources(ctx context.Context, songID int, sources []entities.FieldSource) error
+	MarkEnriched(ctx context.Context, songID int, at time.Time) error
+	MarkEnrichmentFailed(ctx context.Context, songID int) error
+	GetStaleSongIDs(ctx context.Context, enrichedBefore time.Time, limit int) ([]int, error)
+	SaveReview(ctx context.Context, review entities.EnrichmentReview) (entities.EnrichmentReview, bool, error)
+	GetReviews(ctx context.Context, status string, pagination entities.Pagination) ([]entities.EnrichmentReview, error)
+	GetReview(ctx context.Context, id int) (entities.EnrichmentReview, error)
+	ResolveReview(ctx context.Context, id int, status, actor string) (entities.EnrichmentReview, error)
+}
+
+type EnrichmentRepository struct {
+	db   *sql.DB
+	logg *logger.Logger
+}
+
+func NewEnrichmentRepository(db *sql.DB, logg *logger.Logger) *EnrichmentRepository {
+	return &EnrichmentRepository{
+		db:   db,
+		logg: logg,
+	}
+}
+
+func (r *EnrichmentRepository) GetFieldSources(ctx context.Context, songID int) ([]entities.FieldSource, error) {
+	query := `SELECT field, provider, enriched_at FROM song_field_sources WHERE song_id = $1 ORDER BY field`
+	r.logg.WithField("song_id", songID).Debug("Executing query to fetch field sources")
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, songID)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetFieldSources query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []entities.FieldSource{}
+	for rows.Next() {
+		var source entities.FieldSource
+		if err := rows.Scan(&source.Field, &source.Provider, &source.EnrichedAt); err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetFieldSources")
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+// SaveFieldSources records where fields of a song came from, replacing
+// earlier sources of the same fields.
+func (r *EnrichmentRepository) SaveFieldSources(ctx context.Context, songID int, sources []entities.FieldSource) error {
+	query := `INSERT INTO song_field_sources (song_id, field, provider, enriched_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id, field) DO UPDATE SET provider = EXCLUDED.provider, enriched_at = EXCLUDED.enriched_at`
+
+	return runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		for _, source := range sources {
+			if _, err := tx.ExecContext(ctx, query, songID, source.Field, source.Provider, source.EnrichedAt); err != nil {
+				r.logg.WithError(err).Error("Failed to execute SaveFieldSources query")
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkEnriched records when a song was last enriched or checked upstream
+// and clears earlier failures.
+func (r *EnrichmentRepository) MarkEnriched(ctx context.Context, songID int, at time.Time) error {
+	query := `UPDATE songs SET enriched_at = $2, enrichment_failures = 0, enrichment_retry_at = NULL WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, songID, at); err != nil {
+		r.logg.WithError(err).Error("Failed to execute MarkEnriched query")
+		return err
+	}
+	return nil
+}
+
+// MarkEnrichmentFailed counts a failed refresh of a song and puts off the
+// next attempt, by an hour after the first failure and twice as long after
+// each further one, up to a week.
+func (r *EnrichmentRepository) MarkEnrichmentFailed(ctx context.Context, songID int) error {
+	query := `UPDATE songs SET enrichment_failures = enrichment_failures + 1,
+		enrichment_retry_at = now() + LEAST(INTERVAL '1 hour' * power(2, LEAST(enrichment_failures, 8)), INTERVAL '7 days')
+		WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, songID); err != nil {
+		r.logg.WithError(err).Error("Failed to execute MarkEnrichmentFailed query")
+		return err
+	}
+	return nil
+}
+
+// GetStaleSongIDs returns live songs last enriched before enrichedBefore,
+// the stalest first. Songs that were never enriched are not included, nor
+// songs whose next attempt after a failure is not due yet.
+func (r *EnrichmentRepository) GetStaleSongIDs(ctx context.Context, enrichedBefore time.Time, limit int) ([]int, error) {
+	query := `SELECT id FROM songs
+		WHERE deleted_at IS NULL AND enriched_at < $1 AND (enrichment_retry_at IS NULL OR enrichment_retry_at <= now())
+		ORDER BY enriched_at, id LIMIT $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, enrichedBefore, limit)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetStaleSongIDs query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetStaleSongIDs")
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+const enrichmentReviewColumns = `id, song_id, field, current_value, proposed_value, provider, status, created_at, resolved_at, COALESCE(resolved_by, '')`
+
+// SaveReview opens a review, or updates the pending review of the same field
+// with the new values. It reports false and saves nothing when the same
+// value was already rejected for the field.
+func (r *EnrichmentRepository) SaveReview(ctx context.Context, review entities.EnrichmentReview) (entities.EnrichmentReview, bool, error) {
+	query := `INSERT INTO enrichment_reviews (song_id, field, current_value, proposed_value, provider) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (song_id, field) WHERE status = 'pending' DO UPDATE SET current_value = EXCLUDED.current_value,
+			proposed_value = EXCLUDED.proposed_value, provider = EXCLUDED.provider
+		RETURNING ` + enrichmentReviewColumns
+	r.logg.WithFields(logrus.Fields{
+		"song_id": review.SongID,
+		"field":   review.Field,
+	}).Debug("Executing query to save enrichment review")
+
+	var rejected bool
+	if err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM enrichment_reviews
+		WHERE song_id = $1 AND field = $2 AND proposed_value = $3 AND status = 'rejected')`,
+		review.SongID, review.Field, review.ProposedValue).Scan(&rejected); err != nil {
+		r.logg.WithError(err).Error("Failed to check rejected enrichment reviews")
+		return entities.EnrichmentReview{}, false, err
+	}
+	if rejected {
+		return entities.EnrichmentReview{}, false, nil
+	}
+
+	saved, err := scanEnrichmentReview(conn(ctx, r.db).QueryRowContext(ctx, query,
+		review.SongID, review.Field, review.CurrentValue, review.ProposedValue, review.Provider))
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute SaveReview query")
+		return entities.EnrichmentReview{}, false, err
+	}
+	return saved, true, nil
+}
+
+// GetReviews returns reviews with the given status, or all reviews when
+// status is empty, oldest first.
+func (r *EnrichmentRepository) GetReviews(ctx context.Context, status string, pagination entities.Pagination) ([]entities.EnrichmentReview, error) {
+	query := `SELECT ` + enrichmentReviewColumns + ` FROM enrichment_reviews WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at, id LIMIT $2 OFFSET $3`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, status, pagination.PerPage, (pagination.Page-1)*pagination.PerPage)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetReviews query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []entities.EnrichmentReview{}
+	for rows.Next() {
+		review, err := scanEnrichmentReview(rows)
+		if err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetReviews")
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *EnrichmentRepository) GetReview(ctx context.Context, id int) (entities.EnrichmentReview, error) {
+	query := `SELECT ` + enrichmentReviewColumns + ` FROM enrichment_reviews WHERE id = $1`
+
+	review, err := scanEnrichmentReview(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetReview query")
+		}
+		return entities.EnrichmentReview{}, err
+	}
+	return review, nil
+}
+
+// ResolveReview closes a pending review. It returns sql.ErrNoRows when the
+// review does not exist or is already resolved.
+func (r *EnrichmentRepository) ResolveReview(ctx context.Context, id int, status, actor string) (entities.EnrichmentReview, error) {
+	query := `UPDATE enrichment_reviews SET status = $2, resolved_at = now(), resolved_by = $3
+		WHERE id = $1 AND status = 'pending' RETURNING ` + enrichmentReviewColumns
+	r.logg.WithFields(logrus.Fields{
+		"review_id": id,
+		"status":    status,
+	}).Debug("Executing query to resolve enrichment review")
+
+	review, err := scanEnrichmentReview(conn(ctx, r.db).QueryRowContext(ctx, query, id, status, actor))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute ResolveReview query")
+		}
+		return entities.EnrichmentReview{}, err
+	}
+	return review, nil
+}
+
+func scanEnrichmentReview(row rowScanner) (entities.EnrichmentReview, error) {
+	var review entities.EnrichmentReview
+	err := row.Scan(&review.ID, &review.SongID, &review.Field, &review.CurrentValue, &review.ProposedValue, &review.Provider,
+		&review.Status, &review.CreatedAt, &review.ResolvedAt, &review.ResolvedBy)
+	return review, err
+}
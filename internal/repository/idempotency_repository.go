@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type IdempotencyRepositoryInterface interface {
+	ReserveKey(ctx context.Context, record entities.IdempotencyRecord) (entities.IdempotencyRecord, bool, error)
+	CompleteKey(ctx context.Context, record entities.IdempotencyRecord) error
+	ReleaseKey(ctx context.Context, scope, key string) error
+	DeleteExpiredKeys(ctx context.Context) (int, error)
+}
+
+type IdempotencyRepository struct {
+	db   *sql.DB
+	logg *logger.Logger
+}
+
+func NewIdempotencyRepository(db *sql.DB, logg *logger.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:   db,
+		logg: logg,
+	}
+}
+
+const idempotencyColumns = `scope, key, fingerprint, status_code, header, body, created_at, expires_at`
+
+// ReserveKey claims record.Key for a new request. An expired record under the
+// same key is replaced. When the key is already taken, the existing record is
+// returned with reserved set to false.
+func (r *IdempotencyRepository) ReserveKey(ctx context.Context, record entities.IdempotencyRecord) (entities.IdempotencyRecord, bool, error) {
+	query := `INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, header = NULL, body = NULL,
+				created_at = now(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= now()
+		RETURNING ` + idempotencyColumns
+	r.logg.WithFields(logrus.Fields{
+		"scope": record.Scope,
+		"key":   record.Key,
+	}).Debug("Reserving idempotency key")
+
+	reserved, err := scanIdempotencyRecord(r.db.QueryRowContext(ctx, query, record.Scope, record.Key, record.Fingerprint, record.ExpiresAt))
+	if err == nil {
+		return reserved, true, nil
+	}
+	if err != sql.ErrNoRows {
+		r.logg.WithError(err).Error("Failed to execute ReserveKey query")
+		return entities.IdempotencyRecord{}, false, err
+	}
+
+	existing, err := scanIdempotencyRecord(r.db.QueryRowContext(ctx,
+		`SELECT `+idempotencyColumns+` FROM idempotency_keys WHERE scope = $1 AND key = $2`, record.Scope, record.Key))
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to fetch existing idempotency key")
+		return entities.IdempotencyRecord{}, false, err
+	}
+	return existing, false, nil
+}
+
+func (r *IdempotencyRepository) CompleteKey(ctx context.Context, record entities.IdempotencyRecord) error {
+	query := `UPDATE idempotency_keys SET status_code = $3, header = $4, body = $5 WHERE scope = $1 AND key = $2`
+
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, query, record.Scope, record.Key, record.StatusCode, string(header), record.Body); err != nil {
+		r.logg.WithError(err).Error("Failed to execute CompleteKey query")
+		return err
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) ReleaseKey(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2`
+
+	if _, err := r.db.ExecContext(ctx, query, scope, key); err != nil {
+		r.logg.WithError(err).Error("Failed to execute ReleaseKey query")
+		return err
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpiredKeys(ctx context.Context) (int, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+
+	res, err := r.db.ExecContext(ctx, query, time.Now())
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute DeleteExpiredKeys query")
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
+
+func scanIdempotencyRecord(row rowScanner) (entities.IdempotencyRecord, error) {
+	var record entities.IdempotencyRecord
+	var status sql.NullInt64
+	var header []byte
+	err := row.Scan(&record.Scope, &record.Key, &record.Fingerprint, &status, &header, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		return entities.IdempotencyRecord{}, err
+	}
+	record.StatusCode = int(status.Int64)
+	if len(header) > 0 {
+		if err := json.Unmarshal(header, &record.Header); err != nil {
+			return entities.IdempotencyRecord{}, err
+		}
+	}
+	return record, nil
+}
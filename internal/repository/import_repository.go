@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/releasedate"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+type ImportRepositoryInterface interface {
+	CreateImportJob(ctx context.Context, job entities.ImportJob) (entities.ImportJob, error)
+	UpdateImportJob(ctx context.Context, job entities.ImportJob) error
+	GetImportJob(ctx context.Context, id int) (entities.ImportJob, error)
+	ImportSongs(ctx context.Context, jobID int, rows []entities.ImportRow, dryRun bool) (duplicates []int, inserted int, err error)
+}
+
+type ImportRepository struct {
+	db    *sql.DB
+	audit AuditRepositoryInterface
+	logg  *logger.Logger
+}
+
+func NewImportRepository(db *sql.DB, audit AuditRepositoryInterface, logg *logger.Logger) *ImportRepository {
+	return &ImportRepository{
+		db:    db,
+		audit: audit,
+		logg:  logg,
+	}
+}
+
+const importJobColumns = `id, format, dry_run, status, rows_processed, rows_inserted, rows_failed,
+	bytes_total, bytes_processed, errors, error, created_by, created_at, started_at, finished_at`
+
+func (r *ImportRepository) CreateImportJob(ctx context.Context, job entities.ImportJob) (entities.ImportJob, error) {
+	query := `INSERT INTO import_jobs (format, dry_run, status, bytes_total, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING ` + importJobColumns
+	r.logg.WithField("query", query).Debug("Executing query to create import job")
+
+	created, err := scanImportJob(r.db.QueryRowContext(ctx, query, job.Format, job.DryRun, job.Status, job.BytesTotal, job.CreatedBy))
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute CreateImportJob query")
+		return entities.ImportJob{}, err
+	}
+
+	r.logg.WithField("job_id", created.ID).Info("Import job created successfully")
+	return created, nil
+}
+
+func (r *ImportRepository) UpdateImportJob(ctx context.Context, job entities.ImportJob) error {
+	query := `UPDATE import_jobs SET status = $2, rows_processed = $3, rows_inserted = $4, rows_failed = $5,
+		bytes_processed = $6, errors = $7, error = $8, started_at = $9, finished_at = $10 WHERE id = $1`
+
+	errs, err := json.Marshal(job.Errors)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, job.ID, job.Status, job.RowsProcessed, job.RowsInserted, job.RowsFailed,
+		job.BytesProcessed, string(errs), job.Error, job.StartedAt, job.FinishedAt)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute UpdateImportJob query")
+		return err
+	}
+	return nil
+}
+
+func (r *ImportRepository) GetImportJob(ctx context.Context, id int) (entities.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1`
+
+	job, err := scanImportJob(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetImportJob query")
+		}
+		return entities.ImportJob{}, err
+	}
+	return job, nil
+}
+
+// ImportSongs loads a batch of rows with COPY into a staging table, drops
+// rows that would duplicate a live song or an earlier row of the batch, and
+// inserts the rest. The row numbers of dropped rows are returned. With dryRun
+// the transaction is rolled back, so nothing is written.
+func (r *ImportRepository) ImportSongs(ctx context.Context, jobID int, rows []entities.ImportRow, dryRun bool) ([]int, int, error) {
+	r.logg.WithFields(logrus.Fields{
+		"job_id": jobID,
+		"rows":   len(rows),
+	}).Debug("Importing batch of songs")
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TEMP TABLE import_staging (
+		row_no INT NOT NULL,
+		group_name VARCHAR(255) NOT NULL,
+		song_name VARCHAR(255) NOT NULL,
+		release_date DATE NOT NULL,
+		release_date_precision VARCHAR(5) NOT NULL,
+		text TEXT NOT NULL,
+		link TEXT NOT NULL,
+		allow_duplicate BOOLEAN NOT NULL,
+		explicit BOOLEAN NOT NULL
+	) ON COMMIT DROP`); err != nil {
+		return nil, 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("import_staging", "row_no", "group_name", "song_name", "release_date", "release_date_precision", "text", "link", "allow_duplicate", "explicit"))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, row := range rows {
+		song := row.Song
+		released, err := releasedate.Parse(song.ReleaseDate)
+		if err != nil {
+			stmt.Close()
+			return nil, 0, fmt.Errorf("row %d: %w", row.Row, err)
+		}
+		if _, err := stmt.ExecContext(ctx, row.Row, song.GroupName, song.SongName, released.Time.Format(time.DateOnly), released.Precision,
+			song.Text, song.Link, song.AllowDuplicate, song.Explicit); err != nil {
+			stmt.Close()
+			return nil, 0, err
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return nil, 0, err
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	var duplicates []int64
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(array_agg(row_no ORDER BY row_no), '{}') FROM (
+		SELECT st.row_no,
+			row_number() OVER (PARTITION BY song_dedup_key(st.group_name), song_dedup_key(st.song_name) ORDER BY st.row_no) AS rn,
+			EXISTS (
+				SELECT 1 FROM songs s
+				WHERE s.group_key = song_dedup_key(st.group_name) AND s.song_key = song_dedup_key(st.song_name)
+					AND NOT s.allow_duplicate AND s.deleted_at IS NULL
+			) AS clash
+		FROM import_staging st
+		WHERE NOT st.allow_duplicate
+	) checked WHERE rn > 1 OR clash`).Scan(pq.Array(&duplicates))
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(duplicates) > 0 {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM import_staging WHERE row_no = ANY($1)`, pq.Array(duplicates)); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var songIDs []int64
+	err = tx.QueryRowContext(ctx, `WITH inserted AS (
+		INSERT INTO songs (group_name, song_name, release_date, release_date_precision, text, link, allow_duplicate, explicit)
+		SELECT group_name, song_name, release_date, release_date_precision, text, link, allow_duplicate, explicit FROM import_staging ORDER BY row_no
+		RETURNING id
+	) SELECT COALESCE(array_agg(id ORDER BY id), '{}') FROM inserted`).Scan(pq.Array(&songIDs))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rowNumbers := make([]int, len(duplicates))
+	for i, row := range duplicates {
+		rowNumbers[i] = int(row)
+	}
+	if dryRun {
+		return rowNumbers, len(songIDs), nil
+	}
+
+	summary := map[string]interface{}{"inserted": len(songIDs), "song_ids": songIDs}
+	if err := r.audit.RecordEvent(ctx, tx, entities.AuditActionImport, entities.AuditEntityImportJob, jobID, nil, summary); err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+
+	r.logg.WithFields(logrus.Fields{
+		"job_id":   jobID,
+		"inserted": len(songIDs),
+	}).Info("Imported batch of songs successfully")
+	return rowNumbers, len(songIDs), nil
+}
+
+func scanImportJob(row rowScanner) (entities.ImportJob, error) {
+	var job entities.ImportJob
+	var errs []byte
+	err := row.Scan(&job.ID, &job.Format, &job.DryRun, &job.Status, &job.RowsProcessed, &job.RowsInserted, &job.RowsFailed,
+		&job.BytesTotal, &job.BytesProcessed, &errs, &job.Error, &job.CreatedBy, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return job, err
+	}
+	err = json.Unmarshal(errs, &job.Errors)
+	return job, err
+}
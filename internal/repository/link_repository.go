@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
+	"github.com/senyabanana/library-service/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LinkRepositoryInterface interface {
+	GetLinks(ctx context.Context, songID int) ([]entities.SongLink, error)
+	AddLink(ctx context.Context, link entities.SongLink) (entities.SongLink, error)
+	DeleteLink(ctx context.Context, songID, linkID int) error
+	SetPrimaryLink(ctx context.Context, songID, linkID int) (entities.SongLink, error)
+	GetLinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]entities.SongLink, error)
+	SaveCheckResult(ctx context.Context, link entities.SongLink) error
+}
+
+// ErrLinkExists is returned when a song already has a link with the same
+// URL.
+var ErrLinkExists = errors.New("song already has this link")
+
+type LinkRepository struct {
+	db    *sql.DB
+	audit AuditRepositoryInterface
+	logg  *logger.Logger
+}
+
+func NewLinkRepository(db *sql.DB, audit AuditRepositoryInterface, logg *logger.Logger) *LinkRepository {
+	return &LinkRepository{
+		db:    db,
+		audit: audit,
+		logg:  logg,
+	}
+}
+
+const songLinkColumns = `id, song_id, url, is_primary, status, http_status, failures, checked_at, created_at`
+
+func (r *LinkRepository) GetLinks(ctx context.Context, songID int) ([]entities.SongLink, error) {
+	query := `SELECT ` + songLinkColumns + ` FROM song_links WHERE song_id = $1 ORDER BY is_primary DESC, id`
+	r.logg.WithField("song_id", songID).Debug("Executing query to fetch song links")
+
+	return r.queryLinks(ctx, query, songID)
+}
+
+// AddLink adds a link to a song. A primary link is also written to
+// songs.link, which makes it the primary one.
+func (r *LinkRepository) AddLink(ctx context.Context, link entities.SongLink) (entities.SongLink, error) {
+	query := `INSERT INTO song_links (song_id, url) VALUES ($1, $2) ON CONFLICT (song_id, url) DO NOTHING RETURNING id`
+	r.logg.WithFields(logrus.Fields{
+		"song_id": link.SongID,
+		"url":     link.URL,
+	}).Debug("Executing query to add song link")
+
+	var added entities.SongLink
+	err := runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		var id int
+		if err := tx.QueryRowContext(ctx, query, link.SongID, link.URL).Scan(&id); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrLinkExists
+			}
+			return err
+		}
+		if link.Primary {
+			if _, err := tx.ExecContext(ctx, `UPDATE songs SET link = $2 WHERE id = $1`, link.SongID, link.URL); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if added, err = scanSongLink(tx.QueryRowContext(ctx, `SELECT `+songLinkColumns+` FROM song_links WHERE id = $1`, id)); err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionCreate, entities.AuditEntitySongLink, id, nil, added)
+	})
+	if err != nil {
+		if err != ErrLinkExists {
+			r.logg.WithError(err).Error("Failed to add song link")
+		}
+		return entities.SongLink{}, err
+	}
+	return added, nil
+}
+
+// DeleteLink removes a link. When it was the primary link, the oldest of
+// the remaining links takes its place in songs.link.
+func (r *LinkRepository) DeleteLink(ctx context.Context, songID, linkID int) error {
+	query := `DELETE FROM song_links WHERE id = $1 AND song_id = $2 RETURNING ` + songLinkColumns
+	r.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"link_id": linkID,
+	}).Debug("Executing query to delete song link")
+
+	err := runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSongLink(tx.QueryRowContext(ctx, query, linkID, songID))
+		if err != nil {
+			return err
+		}
+		if before.Primary {
+			if _, err := tx.ExecContext(ctx, `UPDATE songs SET link = COALESCE((SELECT url FROM song_links WHERE song_id = $1 ORDER BY id LIMIT 1), '')
+				WHERE id = $1`, songID); err != nil {
+				return err
+			}
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionDelete, entities.AuditEntitySongLink, linkID, before, nil)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute DeleteLink query")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *LinkRepository) SetPrimaryLink(ctx context.Context, songID, linkID int) (entities.SongLink, error) {
+	query := `SELECT ` + songLinkColumns + ` FROM song_links WHERE id = $1 AND song_id = $2 FOR UPDATE`
+	r.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"link_id": linkID,
+	}).Debug("Executing query to set primary song link")
+
+	var updated entities.SongLink
+	err := runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSongLink(tx.QueryRowContext(ctx, query, linkID, songID))
+		if err != nil {
+			return err
+		}
+		if before.Primary {
+			updated = before
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE songs SET link = $2 WHERE id = $1`, songID, before.URL); err != nil {
+			return err
+		}
+		if updated, err = scanSongLink(tx.QueryRowContext(ctx, query, linkID, songID)); err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionUpdate, entities.AuditEntitySongLink, linkID, before, updated)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to set primary song link")
+		}
+		return entities.SongLink{}, err
+	}
+	return updated, nil
+}
+
+// GetLinksToCheck returns links of live songs that have never been checked
+// or were last checked before checkedBefore, least recently checked first.
+func (r *LinkRepository) GetLinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]entities.SongLink, error) {
+	query := `SELECT l.id, l.song_id, l.url, l.is_primary, l.status, l.http_status, l.failures, l.checked_at, l.created_at
+		FROM song_links l JOIN songs s ON s.id = l.song_id
+		WHERE s.deleted_at IS NULL AND (l.checked_at IS NULL OR l.checked_at < $1)
+		ORDER BY l.checked_at NULLS FIRST, l.id LIMIT $2`
+
+	return r.queryLinks(ctx, query, checkedBefore, limit)
+}
+
+// SaveCheckResult stores the outcome of a link check. Check results are not
+// audited.
+func (r *LinkRepository) SaveCheckResult(ctx context.Context, link entities.SongLink) error {
+	query := `UPDATE song_links SET status = $2, http_status = $3, failures = $4, checked_at = $5 WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, link.ID, link.Status, link.HTTPStatus, link.Failures, link.CheckedAt); err != nil {
+		r.logg.WithError(err).Error("Failed to execute SaveCheckResult query")
+		return err
+	}
+	return nil
+}
+
+func (r *LinkRepository) queryLinks(ctx context.Context, query string, args ...interface{}) ([]entities.SongLink, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to query song links")
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []entities.SongLink{}
+	for rows.Next() {
+		link, err := scanSongLink(rows)
+		if err != nil {
+			r.logg.WithError(err).Error("Failed to scan song link row")
+			return nil, err
+		}
+		result = append(result, link)
+	}
+	return result, rows.Err()
+}
+
+func scanSongLink(row rowScanner) (entities.SongLink, error) {
+	var link entities.SongLink
+	if err := row.Scan(&link.ID, &link.SongID, &link.URL, &link.Primary, &link.Status, &link.HTTPStatus, &link.Failures,
+		&link.CheckedAt, &link.CreatedAt); err != nil {
+		return entities.SongLink{}, err
+	}
+	link.Provider = links.Detect(link.URL)
+	return link, nil
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LyricsRepositoryInterface interface {
+	GetSyncedLyrics(ctx context.Context, songID int) (entities.SyncedLyrics, error)
+	SaveSyncedLyrics(ctx context.Context, lyrics entities.SyncedLyrics) (entities.SyncedLyrics, error)
+	DeleteSyncedLyrics(ctx context.Context, songID int) error
+	GetLyricsVersions(ctx context.Context, songID int) ([]entities.SongLyrics, error)
+	GetLyrics(ctx context.Context, songID int, language string) (entities.SongLyrics, error)
+	SaveTranslation(ctx context.Context, lyrics entities.SongLyrics) (entities.SongLyrics, error)
+	DeleteTranslation(ctx context.Context, songID int, language string) error
+	SetOriginalLanguage(ctx context.Context, songID int, language string) (entities.SongLyrics, error)
+	SaveSections(ctx context.Context, lyrics entities.SongLyrics) error
+	GetOriginalLanguages(ctx context.Context) (map[int]string, error)
+}
+
+var (
+	// ErrOriginalLyrics is returned when a translation would overwrite the
+	// original version, which is edited through the song itself.
+	ErrOriginalLyrics = errors.New("language is the original version of the lyrics")
+	// ErrLanguageTaken is returned when the original is relabelled to a
+	// language that already has a translation.
+	ErrLanguageTaken = errors.New("a translation in this language already exists")
+)
+
+type LyricsRepository struct {
+	db    *sql.DB
+	audit AuditRepositoryInterface
+	logg  *logger.Logger
+}
+
+func NewLyricsRepository(db *sql.DB, audit AuditRepositoryInterface, logg *logger.Logger) *LyricsRepository {
+	return &LyricsRepository{
+		db:    db,
+		audit: audit,
+		logg:  logg,
+	}
+}
+
+const (
+	syncedLyricsColumns = `song_id, metadata, lines, updated_at`
+	songLyricsColumns   = `song_id, language, is_original, text, sections, updated_at`
+)
+
+func (r *LyricsRepository) GetSyncedLyrics(ctx context.Context, songID int) (entities.SyncedLyrics, error) {
+	query := `SELECT ` + syncedLyricsColumns + ` FROM song_synced_lyrics WHERE song_id = $1`
+	r.logg.WithField("song_id", songID).Debug("Executing query to fetch synced lyrics")
+
+	lyrics, err := scanSyncedLyrics(conn(ctx, r.db).QueryRowContext(ctx, query, songID))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetSyncedLyrics query")
+		}
+		return entities.SyncedLyrics{}, err
+	}
+	return lyrics, nil
+}
+
+func (r *LyricsRepository) SaveSyncedLyrics(ctx context.Context, lyrics entities.SyncedLyrics) (entities.SyncedLyrics, error) {
+	query := `INSERT INTO song_synced_lyrics (song_id, metadata, lines) VALUES ($1, $2, $3)
+		ON CONFLICT (song_id) DO UPDATE SET metadata = EXCLUDED.metadata, lines = EXCLUDED.lines, updated_at = now()
+		RETURNING ` + syncedLyricsColumns
+	r.logg.WithField("song_id", lyrics.SongID).Debug("Executing query to save synced lyrics")
+
+	metadata, err := json.Marshal(lyrics.Metadata)
+	if err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+	lines, err := json.Marshal(lyrics.Lines)
+	if err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+
+	var saved entities.SyncedLyrics
+	err = runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSyncedLyrics(tx.QueryRowContext(ctx, `SELECT `+syncedLyricsColumns+` FROM song_synced_lyrics WHERE song_id = $1 FOR UPDATE`, lyrics.SongID))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if saved, err = scanSyncedLyrics(tx.QueryRowContext(ctx, query, lyrics.SongID, string(metadata), string(lines))); err != nil {
+			return err
+		}
+		action := entities.AuditActionUpdate
+		var previous interface{} = before
+		if before.SongID == 0 {
+			action, previous = entities.AuditActionCreate, nil
+		}
+		return r.audit.RecordEvent(ctx, tx, action, entities.AuditEntitySyncedLyrics, lyrics.SongID, previous, saved)
+	})
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to save synced lyrics")
+		return entities.SyncedLyrics{}, err
+	}
+	return saved, nil
+}
+
+func (r *LyricsRepository) DeleteSyncedLyrics(ctx context.Context, songID int) error {
+	query := `DELETE FROM song_synced_lyrics WHERE song_id = $1 RETURNING ` + syncedLyricsColumns
+	r.logg.WithField("song_id", songID).Debug("Executing query to delete synced lyrics")
+
+	err := runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSyncedLyrics(tx.QueryRowContext(ctx, query, songID))
+		if err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionDelete, entities.AuditEntitySyncedLyrics, songID, before, nil)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute DeleteSyncedLyrics query")
+		}
+		return err
+	}
+	return nil
+}
+
+// GetLyricsVersions returns every version of a song's lyrics, the original
+// first.
+func (r *LyricsRepository) GetLyricsVersions(ctx context.Context, songID int) ([]entities.SongLyrics, error) {
+	query := `SELECT ` + songLyricsColumns + ` FROM song_lyrics WHERE song_id = $1 ORDER BY is_original DESC, language`
+	r.logg.WithField("song_id", songID).Debug("Executing query to fetch lyrics versions")
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, songID)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetLyricsVersions query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []entities.SongLyrics{}
+	for rows.Next() {
+		lyrics, err := scanSongLyrics(rows)
+		if err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetLyricsVersions")
+			return nil, err
+		}
+		versions = append(versions, lyrics)
+	}
+	return versions, rows.Err()
+}
+
+// GetLyrics returns the lyrics in language, or the original version when
+// language is empty.
+func (r *LyricsRepository) GetLyrics(ctx context.Context, songID int, language string) (entities.SongLyrics, error) {
+	query := `SELECT ` + songLyricsColumns + ` FROM song_lyrics WHERE song_id = $1 AND (language = $2 OR ($2 = '' AND is_original))`
+
+	lyrics, err := scanSongLyrics(conn(ctx, r.db).QueryRowContext(ctx, query, songID, language))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetLyrics query")
+		}
+		return entities.SongLyrics{}, err
+	}
+	return lyrics, nil
+}
+
+func (r *LyricsRepository) SaveTranslation(ctx context.Context, lyrics entities.SongLyrics) (entities.SongLyrics, error) {
+	query := `INSERT INTO song_lyrics (song_id, language, text, sections) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id, language) DO UPDATE SET text = EXCLUDED.text, sections = EXCLUDED.sections, updated_at = now()
+			WHERE NOT song_lyrics.is_original
+		RETURNING ` + songLyricsColumns
+	r.logg.WithFields(logrus.Fields{
+		"song_id":  lyrics.SongID,
+		"language": lyrics.Language,
+	}).Debug("Executing query to save translation")
+
+	sections, err := json.Marshal(lyrics.Sections)
+	if err != nil {
+		return entities.SongLyrics{}, err
+	}
+
+	var saved entities.SongLyrics
+	err = runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSongLyrics(tx.QueryRowContext(ctx, `SELECT `+songLyricsColumns+` FROM song_lyrics WHERE song_id = $1 AND language = $2 FOR UPDATE`,
+			lyrics.SongID, lyrics.Language))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if before.Original {
+			return ErrOriginalLyrics
+		}
+		if saved, err = scanSongLyrics(tx.QueryRowContext(ctx, query, lyrics.SongID, lyrics.Language, lyrics.Text, string(sections))); err != nil {
+			return err
+		}
+		action := entities.AuditActionUpdate
+		var previous interface{} = before
+		if before.SongID == 0 {
+			action, previous = entities.AuditActionCreate, nil
+		}
+		return r.audit.RecordEvent(ctx, tx, action, entities.AuditEntitySongLyrics, lyrics.SongID, previous, saved)
+	})
+	if err != nil {
+		if err != ErrOriginalLyrics {
+			r.logg.WithError(err).Error("Failed to save translation")
+		}
+		return entities.SongLyrics{}, err
+	}
+	return saved, nil
+}
+
+func (r *LyricsRepository) DeleteTranslation(ctx context.Context, songID int, language string) error {
+	query := `DELETE FROM song_lyrics WHERE song_id = $1 AND language = $2 AND NOT is_original RETURNING ` + songLyricsColumns
+	r.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"language": language,
+	}).Debug("Executing query to delete translation")
+
+	err := runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSongLyrics(tx.QueryRowContext(ctx, query, songID, language))
+		if err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionDelete, entities.AuditEntitySongLyrics, songID, before, nil)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute DeleteTranslation query")
+		}
+		return err
+	}
+	return nil
+}
+
+// SetOriginalLanguage records which language the original lyrics are in.
+func (r *LyricsRepository) SetOriginalLanguage(ctx context.Context, songID int, language string) (entities.SongLyrics, error) {
+	query := `UPDATE song_lyrics SET language = $2, updated_at = now() WHERE song_id = $1 AND is_original RETURNING ` + songLyricsColumns
+	r.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"language": language,
+	}).Debug("Executing query to set original language")
+
+	var updated entities.SongLyrics
+	err := runInTx(ctx, r.db, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := scanSongLyrics(tx.QueryRowContext(ctx, `SELECT `+songLyricsColumns+` FROM song_lyrics WHERE song_id = $1 AND is_original FOR UPDATE`, songID))
+		if err != nil {
+			return err
+		}
+		if before.Language == language {
+			updated = before
+			return nil
+		}
+
+		var taken bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM song_lyrics WHERE song_id = $1 AND language = $2)`, songID, language).Scan(&taken); err != nil {
+			return err
+		}
+		if taken {
+			return ErrLanguageTaken
+		}
+
+		if updated, err = scanSongLyrics(tx.QueryRowContext(ctx, query, songID, language)); err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionUpdate, entities.AuditEntitySongLyrics, songID, before, updated)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows && err != ErrLanguageTaken {
+			r.logg.WithError(err).Error("Failed to set original language")
+		}
+		return entities.SongLyrics{}, err
+	}
+	return updated, nil
+}
+
+// SaveSections stores the parsed text of a version, unless the text has
+// changed since it was read.
+func (r *LyricsRepository) SaveSections(ctx context.Context, lyrics entities.SongLyrics) error {
+	query := `UPDATE song_lyrics SET sections = $4 WHERE song_id = $1 AND language = $2 AND text = $3`
+
+	sections, err := json.Marshal(lyrics.Sections)
+	if err != nil {
+		return err
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, lyrics.SongID, lyrics.Language, lyrics.Text, string(sections)); err != nil {
+		r.logg.WithError(err).Error("Failed to execute SaveSections query")
+		return err
+	}
+	return nil
+}
+
+// GetOriginalLanguages maps song IDs to the language of their original
+// lyrics, for the songs where it is known.
+func (r *LyricsRepository) GetOriginalLanguages(ctx context.Context) (map[int]string, error) {
+	query := `SELECT song_id, language FROM song_lyrics WHERE is_original AND language <> 'und'`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetOriginalLanguages query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	languages := map[int]string{}
+	for rows.Next() {
+		var songID int
+		var language string
+		if err := rows.Scan(&songID, &language); err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetOriginalLanguages")
+			return nil, err
+		}
+		languages[songID] = language
+	}
+	return languages, rows.Err()
+}
+
+func scanSongLyrics(row rowScanner) (entities.SongLyrics, error) {
+	var lyrics entities.SongLyrics
+	var sections []byte
+	if err := row.Scan(&lyrics.SongID, &lyrics.Language, &lyrics.Original, &lyrics.Text, &sections, &lyrics.UpdatedAt); err != nil {
+		return entities.SongLyrics{}, err
+	}
+	if sections != nil {
+		if err := json.Unmarshal(sections, &lyrics.Sections); err != nil {
+			return entities.SongLyrics{}, err
+		}
+	}
+	return lyrics, nil
+}
+
+func scanSyncedLyrics(row rowScanner) (entities.SyncedLyrics, error) {
+	var lyrics entities.SyncedLyrics
+	var metadata, lines []byte
+	if err := row.Scan(&lyrics.SongID, &metadata, &lines, &lyrics.UpdatedAt); err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+	if err := json.Unmarshal(metadata, &lyrics.Metadata); err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+	if err := json.Unmarshal(lines, &lyrics.Lines); err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+	return lyrics, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/logger"
+)
+
+// RateLimitRepository is the Postgres store for rate limit counters, shared
+// by all instances of the service.
+type RateLimitRepository struct {
+	db   *sql.DB
+	logg *logger.Logger
+}
+
+func NewRateLimitRepository(db *sql.DB, logg *logger.Logger) *RateLimitRepository {
+	return &RateLimitRepository{
+		db:   db,
+		logg: logg,
+	}
+}
+
+func (r *RateLimitRepository) Increment(ctx context.Context, key string, expiresAt time.Time) (int, error) {
+	query := `INSERT INTO rate_limit_counters (key, count, expires_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET count = rate_limit_counters.count + 1
+		RETURNING count`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, key, expiresAt).Scan(&count); err != nil {
+		r.logg.WithError(err).Error("Failed to execute Increment query")
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *RateLimitRepository) DeleteExpired(ctx context.Context) (int, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_counters WHERE expires_at <= now()`)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute DeleteExpired query")
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/releasedate"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RevisionRepositoryInterface interface {
+	EnsureBaselineRevision(ctx context.Context, songID int) error
+	AddRevision(ctx context.Context, songID int) (int, error)
+	GetRevisions(ctx context.Context, songID int) ([]entities.SongRevision, error)
+	GetRevision(ctx context.Context, songID, revision int) (entities.SongRevision, error)
+}
+
+type RevisionRepository struct {
+	db   *sql.DB
+	logg *logger.Logger
+}
+
+func NewRevisionRepository(db *sql.DB, logg *logger.Logger) *RevisionRepository {
+	return &RevisionRepository{
+		db:   db,
+		logg: logg,
+	}
+}
+
+// snapshotQuery copies the current row of a song into the next revision.
+const snapshotQuery = `INSERT INTO song_revisions (song_id, revision, group_name, song_name, release_date, release_date_precision, text, link, actor)
+	SELECT s.id, COALESCE((SELECT MAX(revision) FROM song_revisions WHERE song_id = s.id), 0) + 1,
+		s.group_name, s.song_name, s.release_date, s.release_date_precision, s.text, s.link, $2
+	FROM songs s WHERE s.id = $1`
+
+// EnsureBaselineRevision snapshots a song as revision 1 if it has no history
+// yet, so that songs created before revisions existed can still be restored
+// to their original state.
+func (r *RevisionRepository) EnsureBaselineRevision(ctx context.Context, songID int) error {
+	query := snapshotQuery + ` AND NOT EXISTS (SELECT 1 FROM song_revisions WHERE song_id = s.id)`
+	r.logg.WithField("song_id", songID).Debug("Ensuring baseline revision")
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, songID, auth.ActorName(ctx)); err != nil {
+		r.logg.WithError(err).Error("Failed to execute EnsureBaselineRevision query")
+		return err
+	}
+	return nil
+}
+
+func (r *RevisionRepository) AddRevision(ctx context.Context, songID int) (int, error) {
+	query := snapshotQuery + ` RETURNING revision`
+	r.logg.WithField("song_id", songID).Debug("Executing query to add song revision")
+
+	var revision int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, songID, auth.ActorName(ctx)).Scan(&revision); err != nil {
+		r.logg.WithError(err).Error("Failed to execute AddRevision query")
+		return 0, err
+	}
+
+	r.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"revision": revision,
+	}).Info("Song revision added successfully")
+	return revision, nil
+}
+
+const revisionColumns = `song_id, revision, group_name, song_name, release_date, release_date_precision, text, link, actor, created_at`
+
+func (r *RevisionRepository) GetRevisions(ctx context.Context, songID int) ([]entities.SongRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM song_revisions WHERE song_id = $1 ORDER BY revision DESC`
+	r.logg.WithField("query", query).Debug("Executing query to fetch song revisions")
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, songID)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetRevisions query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []entities.SongRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetRevisions")
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		r.logg.WithError(err).Error("Failed to iterate rows in GetRevisions")
+		return nil, err
+	}
+
+	r.logg.WithField("count", len(revisions)).Info("Fetched song revisions successfully")
+	return revisions, nil
+}
+
+func (r *RevisionRepository) GetRevision(ctx context.Context, songID, revision int) (entities.SongRevision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM song_revisions WHERE song_id = $1 AND revision = $2`
+
+	rev, err := scanRevision(conn(ctx, r.db).QueryRowContext(ctx, query, songID, revision))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetRevision query")
+		}
+		return entities.SongRevision{}, err
+	}
+	return rev, nil
+}
+
+func scanRevision(row rowScanner) (entities.SongRevision, error) {
+	var rev entities.SongRevision
+	var released time.Time
+	var precision string
+	err := row.Scan(&rev.SongID, &rev.Revision, &rev.GroupName, &rev.SongName, &released, &precision, &rev.Text, &rev.Link, &rev.Actor, &rev.CreatedAt)
+	rev.ReleaseDate = releasedate.Format(released, precision)
+	return rev, err
+}
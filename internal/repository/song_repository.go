@@ -3,52 +3,103 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"time"
 
+	"github.com/senyabanana/library-service/internal/auth"
 	"github.com/senyabanana/library-service/internal/entities"
 	"github.com/senyabanana/library-service/internal/logger"
-	
+	"github.com/senyabanana/library-service/internal/releasedate"
+
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
+// SongColumns lists the columns scanned into entities.Song, in order. Queries
+// passed to GetSongsWithQuery must select exactly these.
+const SongColumns = `id, group_name, song_name, release_date, release_date_precision, text, link, allow_duplicate, explicit, explicit_manual, enriched_at, deleted_at`
+
+const songDedupIndex = "songs_dedup_key_idx"
+
+const streamBatchSize = 500
+
+// ErrDuplicateSong is returned when a write would create a second live song
+// with the same normalized group and song name.
+var ErrDuplicateSong = errors.New("duplicate song")
+
 type SongRepositoryInterface interface {
-	AddSong(ctx context.Context, song entities.Song) error
+	AddSong(ctx context.Context, song entities.Song) (entities.Song, error)
 	GetSongs(ctx context.Context) ([]entities.Song, error)
 	GetSongsWithQuery(ctx context.Context, query string, args ...interface{}) ([]entities.Song, error)
-	UpdateSong(ctx context.Context, song entities.Song) error
+	StreamSongsWithQuery(ctx context.Context, fn func(entities.Song) error, query string, args ...interface{}) error
+	GetSongByID(ctx context.Context, id int) (entities.Song, error)
+	FindDuplicateSong(ctx context.Context, group, song string, excludeID int) (int, error)
+	UpdateSong(ctx context.Context, song entities.Song) (entities.Song, error)
 	DeleteSong(ctx context.Context, id int) error
+	RestoreSong(ctx context.Context, id int) error
+	HardDeleteSong(ctx context.Context, id int) error
+	PurgeDeletedSongs(ctx context.Context, deletedBefore time.Time) (int, error)
+	GetDuplicateReports(ctx context.Context) ([]entities.DuplicateReport, error)
+	MergeSongs(ctx context.Context, merged entities.Song, mergedIDs []int) (entities.MergeMoves, error)
+	GetRedirect(ctx context.Context, oldID int) (int, error)
+	SetExplicit(ctx context.Context, id int, explicit, manual bool) (entities.Song, error)
+	UpdateExplicitFlags(ctx context.Context, explicitIDs, cleanIDs []int) (int, error)
 }
 
 type SongRepository struct {
-	db   *sql.DB
-	logg *logger.Logger
+	db    *sql.DB
+	audit AuditRepositoryInterface
+	logg  *logger.Logger
 }
 
-func NewSongRepository(db *sql.DB, logg *logger.Logger) *SongRepository {
+func NewSongRepository(db *sql.DB, audit AuditRepositoryInterface, logg *logger.Logger) *SongRepository {
 	return &SongRepository{
-		db:   db,
-		logg: logg,
+		db:    db,
+		audit: audit,
+		logg:  logg,
 	}
 }
 
-func (r *SongRepository) AddSong(ctx context.Context, song entities.Song) error {
-	query := `INSERT INTO songs (group_name, song_name, release_date, text, link) VALUES ($1, $2, $3, $4, $5)`
+func (r *SongRepository) AddSong(ctx context.Context, song entities.Song) (entities.Song, error) {
+	query := `INSERT INTO songs (group_name, song_name, release_date, release_date_precision, text, link, allow_duplicate, explicit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING ` + SongColumns
 	r.logg.Debug("Executing query to add song", query)
 
-	_, err := r.db.ExecContext(ctx, query, song.GroupName, song.SongName, song.ReleaseDate, song.Text, song.Link)
+	released, err := releasedate.Parse(song.ReleaseDate)
 	if err != nil {
-		r.logg.WithError(err).Error("Failed to execute AddSong query")
-		return err
+		return entities.Song{}, err
 	}
 
-	r.logg.WithField("song", song.SongName).Info("Song added successfully")
-	return nil
+	var created entities.Song
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		created, err = scanSong(tx.QueryRowContext(ctx, query, song.GroupName, song.SongName, released.Time.Format(time.DateOnly), released.Precision,
+			song.Text, song.Link, song.AllowDuplicate, song.Explicit))
+		if err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionCreate, entities.AuditEntitySong, created.ID, nil, created)
+	})
+	if err != nil {
+		if err = translateSongError(err); err != ErrDuplicateSong {
+			r.logg.WithError(err).Error("Failed to execute AddSong query")
+		}
+		return entities.Song{}, err
+	}
+
+	r.logg.WithFields(logrus.Fields{
+		"song_id": created.ID,
+		"song":    created.SongName,
+	}).Info("Song added successfully")
+	return created, nil
 }
 
 func (r *SongRepository) GetSongs(ctx context.Context) ([]entities.Song, error) {
-	query := `SELECT id, group_name, song_name, release_date, text, link FROM songs`
+	query := `SELECT ` + SongColumns + ` FROM songs WHERE deleted_at IS NULL`
 	r.logg.Debug("Executing query to fetch all songs", query)
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		r.logg.WithError(err).Error("Failed to execute GetSongs query")
 		return nil, err
@@ -57,8 +108,8 @@ func (r *SongRepository) GetSongs(ctx context.Context) ([]entities.Song, error)
 
 	var songs []entities.Song
 	for rows.Next() {
-		var song entities.Song
-		if err := rows.Scan(&song.ID, &song.GroupName, &song.SongName, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
+		song, err := scanSong(rows)
+		if err != nil {
 			r.logg.WithError(err).Error("Failed to scan row in GetSongs")
 			return nil, err
 		}
@@ -72,7 +123,7 @@ func (r *SongRepository) GetSongs(ctx context.Context) ([]entities.Song, error)
 func (r *SongRepository) GetSongsWithQuery(ctx context.Context, query string, args ...interface{}) ([]entities.Song, error) {
 	r.logg.WithField("query", query).Debug("Executing query with filters")
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logg.WithError(err).Error("Failed to execute GetSongsWithQuery")
 		return nil, err
@@ -81,8 +132,8 @@ func (r *SongRepository) GetSongsWithQuery(ctx context.Context, query string, ar
 
 	var songs []entities.Song
 	for rows.Next() {
-		var song entities.Song
-		if err := rows.Scan(&song.ID, &song.GroupName, &song.SongName, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
+		song, err := scanSong(rows)
+		if err != nil {
 			r.logg.WithError(err).Error("Failed to scan row in GetSongsWithQuery")
 			return nil, err
 		}
@@ -93,33 +144,559 @@ func (r *SongRepository) GetSongsWithQuery(ctx context.Context, query string, ar
 	return songs, nil
 }
 
-func (r *SongRepository) UpdateSong(ctx context.Context, song entities.Song) error {
-	query := `UPDATE songs SET group_name = $1, song_name = $2, release_date = $3, text = $4, link = $5 WHERE id = $6`
+// StreamSongsWithQuery runs query through a server-side cursor and calls fn
+// for every row, fetching streamBatchSize rows at a time so large result sets
+// are never held in memory.
+func (r *SongRepository) StreamSongsWithQuery(ctx context.Context, fn func(entities.Song) error, query string, args ...interface{}) error {
+	r.logg.WithField("query", query).Debug("Streaming songs through cursor")
+
+	fetch := `FETCH ` + strconv.Itoa(streamBatchSize) + ` FROM song_stream`
+	total := 0
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DECLARE song_stream NO SCROLL CURSOR FOR `+query, args...); err != nil {
+			return err
+		}
+
+		for {
+			rows, err := tx.QueryContext(ctx, fetch)
+			if err != nil {
+				return err
+			}
+
+			count := 0
+			for rows.Next() {
+				song, err := scanSong(rows)
+				if err == nil {
+					err = fn(song)
+				}
+				if err != nil {
+					rows.Close()
+					return err
+				}
+				count++
+			}
+			if err := rows.Close(); err != nil {
+				return err
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			total += count
+			if count < streamBatchSize {
+				break
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, `CLOSE song_stream`)
+		return err
+	})
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to stream songs through cursor")
+		return err
+	}
+
+	r.logg.WithField("count", total).Info("Streamed songs successfully")
+	return nil
+}
+
+// GetSongByID returns a song by ID, including trashed songs.
+func (r *SongRepository) GetSongByID(ctx context.Context, id int) (entities.Song, error) {
+	query := `SELECT ` + SongColumns + ` FROM songs WHERE id = $1`
+
+	song, err := scanSong(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetSongByID query")
+		}
+		return entities.Song{}, err
+	}
+	return song, nil
+}
+
+// FindDuplicateSong returns the ID of the live song that group and song
+// collide with under the duplicate rules, or sql.ErrNoRows.
+func (r *SongRepository) FindDuplicateSong(ctx context.Context, group, song string, excludeID int) (int, error) {
+	query := `SELECT id FROM songs
+		WHERE group_key = song_dedup_key($1) AND song_key = song_dedup_key($2)
+			AND NOT allow_duplicate AND deleted_at IS NULL AND id <> $3
+		LIMIT 1`
+
+	var id int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, group, song, excludeID).Scan(&id); err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute FindDuplicateSong query")
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (r *SongRepository) UpdateSong(ctx context.Context, song entities.Song) (entities.Song, error) {
+	query := `UPDATE songs SET group_name = $1, song_name = $2, release_date = $3, release_date_precision = $4, text = $5, link = $6,
+		allow_duplicate = $7, explicit = CASE WHEN explicit_manual THEN explicit ELSE $8 END WHERE id = $9`
 	r.logg.WithFields(logrus.Fields{
 		"query": query,
 		"song":  song,
 	}).Debug("Executing query to update song")
 
-	_, err := r.db.ExecContext(ctx, query, song.GroupName, song.SongName, song.ReleaseDate, song.Text, song.Link, song.ID)
+	released, err := releasedate.Parse(song.ReleaseDate)
 	if err != nil {
-		r.logg.WithError(err).Error("Failed to execute UpdateSong query")
-		return err
+		return entities.Song{}, err
+	}
+
+	var updated entities.Song
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.lockSong(ctx, tx, song.ID, false)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, song.GroupName, song.SongName, released.Time.Format(time.DateOnly), released.Precision,
+			song.Text, song.Link, song.AllowDuplicate, song.Explicit, song.ID); err != nil {
+			return err
+		}
+		after, err := r.lockSong(ctx, tx, song.ID, false)
+		if err != nil {
+			return err
+		}
+		updated = *after
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionUpdate, entities.AuditEntitySong, song.ID, before, after)
+	})
+	if err != nil {
+		if err = translateSongError(err); err != sql.ErrNoRows && err != ErrDuplicateSong {
+			r.logg.WithError(err).Error("Failed to execute UpdateSong query")
+		}
+		return entities.Song{}, err
 	}
 
 	r.logg.WithField("song", song.SongName).Info("Song updated successfully")
-	return nil
+	return updated, nil
 }
 
+// DeleteSong moves a song to the trash. Trashed songs are hidden from reads
+// until restored or purged.
 func (r *SongRepository) DeleteSong(ctx context.Context, id int) error {
-	query := `DELETE FROM songs WHERE id = $1`
+	query := `UPDATE songs SET deleted_at = now() WHERE id = $1`
 	r.logg.WithField("query", query).Debug("Executing query to delete song")
 
-	_, err := r.db.ExecContext(ctx, query, id)
+	err := r.changeTrashState(ctx, id, query, false, entities.AuditActionDelete)
 	if err != nil {
-		r.logg.WithError(err).Error("Failed to execute DeleteSong query")
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute DeleteSong query")
+		}
 		return err
 	}
 
 	r.logg.WithField("song_id", id).Info("Song deleted successfully")
 	return nil
 }
+
+func (r *SongRepository) RestoreSong(ctx context.Context, id int) error {
+	query := `UPDATE songs SET deleted_at = NULL WHERE id = $1`
+	r.logg.WithField("query", query).Debug("Executing query to restore song")
+
+	err := r.changeTrashState(ctx, id, query, true, entities.AuditActionRestore)
+	if err != nil {
+		if err = translateSongError(err); err != sql.ErrNoRows && err != ErrDuplicateSong {
+			r.logg.WithError(err).Error("Failed to execute RestoreSong query")
+		}
+		return err
+	}
+
+	r.logg.WithField("song_id", id).Info("Song restored successfully")
+	return nil
+}
+
+func (r *SongRepository) changeTrashState(ctx context.Context, id int, query string, trashed bool, action string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.lockSong(ctx, tx, id, trashed)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+		after, err := r.lockSong(ctx, tx, id, !trashed)
+		if err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, action, entities.AuditEntitySong, id, before, after)
+	})
+}
+
+// HardDeleteSong permanently removes a song, whether or not it is trashed.
+func (r *SongRepository) HardDeleteSong(ctx context.Context, id int) error {
+	query := `DELETE FROM songs WHERE id = $1 RETURNING ` + SongColumns
+	r.logg.WithField("query", query).Debug("Executing query to permanently delete song")
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := scanSong(tx.QueryRowContext(ctx, query, id))
+		if err != nil {
+			return err
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionPurge, entities.AuditEntitySong, id, before, nil)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute HardDeleteSong query")
+		}
+		return err
+	}
+
+	r.logg.WithField("song_id", id).Info("Song permanently deleted successfully")
+	return nil
+}
+
+func (r *SongRepository) PurgeDeletedSongs(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `DELETE FROM songs WHERE deleted_at IS NOT NULL AND deleted_at < $1 RETURNING ` + SongColumns
+	r.logg.WithField("query", query).Debug("Executing query to purge trashed songs")
+
+	var purged []entities.Song
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, deletedBefore)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			song, err := scanSong(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			purged = append(purged, song)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		for _, song := range purged {
+			if err := r.audit.RecordEvent(ctx, tx, entities.AuditActionPurge, entities.AuditEntitySong, song.ID, song, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute PurgeDeletedSongs query")
+		return 0, err
+	}
+
+	r.logg.WithField("count", len(purged)).Info("Trashed songs purged successfully")
+	return len(purged), nil
+}
+
+func (r *SongRepository) GetDuplicateReports(ctx context.Context) ([]entities.DuplicateReport, error) {
+	query := `SELECT song_id, duplicate_of, detected_at FROM song_duplicate_reports ORDER BY duplicate_of, song_id`
+	r.logg.WithField("query", query).Debug("Executing query to fetch duplicate reports")
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute GetDuplicateReports query")
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []entities.DuplicateReport{}
+	for rows.Next() {
+		var report entities.DuplicateReport
+		if err := rows.Scan(&report.SongID, &report.DuplicateOf, &report.DetectedAt); err != nil {
+			r.logg.WithError(err).Error("Failed to scan row in GetDuplicateReports")
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		r.logg.WithError(err).Error("Failed to iterate rows in GetDuplicateReports")
+		return nil, err
+	}
+
+	r.logg.WithField("count", len(reports)).Info("Fetched duplicate reports successfully")
+	return reports, nil
+}
+
+// MergeSongs folds mergedIDs into the song merged.ID in one transaction:
+// their revisions are appended to the survivor's history, redirects are left
+// for their IDs, their links and the translations and synced lyrics the
+// survivor lacks move to it, they are deleted, and the survivor takes the
+// merged values.
+func (r *SongRepository) MergeSongs(ctx context.Context, merged entities.Song, mergedIDs []int) (entities.MergeMoves, error) {
+	r.logg.WithFields(logrus.Fields{
+		"survivor_id": merged.ID,
+		"merged_ids":  mergedIDs,
+	}).Debug("Executing queries to merge songs")
+
+	released, err := releasedate.Parse(merged.ReleaseDate)
+	if err != nil {
+		return entities.MergeMoves{}, err
+	}
+
+	var moves entities.MergeMoves
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		moves = entities.MergeMoves{}
+		rows, err := tx.QueryContext(ctx, `SELECT `+SongColumns+` FROM songs WHERE id = ANY($1) ORDER BY id FOR UPDATE`,
+			pq.Array(append([]int{merged.ID}, mergedIDs...)))
+		if err != nil {
+			return err
+		}
+		var survivor *entities.Song
+		losers := []entities.Song{}
+		for rows.Next() {
+			song, err := scanSong(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			if song.ID == merged.ID {
+				survivor = &song
+			} else {
+				losers = append(losers, song)
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if survivor == nil || len(losers) != len(mergedIDs) {
+			return sql.ErrNoRows
+		}
+
+		actor := auth.ActorName(ctx)
+		baseline := snapshotQuery + ` AND NOT EXISTS (SELECT 1 FROM song_revisions WHERE song_id = s.id)`
+		if _, err := tx.ExecContext(ctx, baseline, merged.ID, actor); err != nil {
+			return err
+		}
+		for _, id := range mergedIDs {
+			if _, err := tx.ExecContext(ctx, `UPDATE song_revisions
+				SET song_id = $1, revision = revision + (SELECT COALESCE(MAX(revision), 0) FROM song_revisions WHERE song_id = $1)
+				WHERE song_id = $2`, merged.ID, id); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE song_redirects SET song_id = $1 WHERE song_id = ANY($2)`, merged.ID, pq.Array(mergedIDs)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO song_redirects (old_id, song_id) SELECT unnest($2::int[]), $1`, merged.ID, pq.Array(mergedIDs)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO song_links (song_id, url, status, http_status, failures, checked_at, created_at)
+			SELECT $1, url, status, http_status, failures, checked_at, created_at FROM song_links WHERE song_id = ANY($2)
+			ON CONFLICT (song_id, url) DO NOTHING`, merged.ID, pq.Array(mergedIDs)); err != nil {
+			return err
+		}
+		if moves.MovedTranslations, err = moveTranslations(ctx, tx, merged.ID, mergedIDs); err != nil {
+			return err
+		}
+		var syncedFrom int
+		err = tx.QueryRowContext(ctx, `WITH source AS (
+				SELECT song_id FROM song_synced_lyrics WHERE song_id = ANY($2) ORDER BY song_id LIMIT 1
+			)
+			UPDATE song_synced_lyrics l SET song_id = $1 FROM source
+			WHERE l.song_id = source.song_id AND NOT EXISTS (SELECT 1 FROM song_synced_lyrics WHERE song_id = $1)
+			RETURNING source.song_id`, merged.ID, pq.Array(mergedIDs)).Scan(&syncedFrom)
+		switch {
+		case err == nil:
+			moves.SyncedLyricsFrom = &syncedFrom
+		case err != sql.ErrNoRows:
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM songs WHERE id = ANY($1)`, pq.Array(mergedIDs)); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE songs SET group_name = $1, song_name = $2, release_date = $3, release_date_precision = $4,
+			text = $5, link = $6, explicit = CASE WHEN explicit_manual THEN explicit ELSE $7 END WHERE id = $8`,
+			merged.GroupName, merged.SongName, released.Time.Format(time.DateOnly), released.Precision, merged.Text, merged.Link, merged.Explicit, merged.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, snapshotQuery, merged.ID, actor); err != nil {
+			return err
+		}
+
+		after, err := r.lockSong(ctx, tx, merged.ID, false)
+		if err != nil {
+			return err
+		}
+		for _, loser := range losers {
+			mergedInto := map[string]int{"merged_into": merged.ID}
+			if err := r.audit.RecordEvent(ctx, tx, entities.AuditActionMerge, entities.AuditEntitySong, loser.ID, loser, mergedInto); err != nil {
+				return err
+			}
+		}
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionMerge, entities.AuditEntitySong, merged.ID, survivor, after)
+	})
+	if err != nil {
+		if err = translateSongError(err); err != sql.ErrNoRows && err != ErrDuplicateSong {
+			r.logg.WithError(err).Error("Failed to merge songs")
+		}
+		return entities.MergeMoves{}, err
+	}
+
+	r.logg.WithField("survivor_id", merged.ID).Info("Songs merged successfully")
+	return moves, nil
+}
+
+// moveTranslations hands the translations of the merged songs over to the
+// survivor for languages it has no lyrics in, so that they are not deleted
+// with the merged songs. It returns the moved languages.
+func moveTranslations(ctx context.Context, tx *sql.Tx, survivorID int, mergedIDs []int) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `UPDATE song_lyrics l SET song_id = $1
+		FROM (
+			SELECT DISTINCT ON (language) song_id, language FROM song_lyrics
+			WHERE song_id = ANY($2) AND NOT is_original
+				AND language NOT IN (SELECT language FROM song_lyrics WHERE song_id = $1)
+			ORDER BY language, song_id
+		) m
+		WHERE l.song_id = m.song_id AND l.language = m.language
+		RETURNING l.language`, survivorID, pq.Array(mergedIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var languages []string
+	for rows.Next() {
+		var language string
+		if err := rows.Scan(&language); err != nil {
+			return nil, err
+		}
+		languages = append(languages, language)
+	}
+	return languages, rows.Err()
+}
+
+func (r *SongRepository) GetRedirect(ctx context.Context, oldID int) (int, error) {
+	query := `SELECT song_id FROM song_redirects WHERE old_id = $1`
+
+	var id int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, oldID).Scan(&id); err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute GetRedirect query")
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+// SetExplicit sets the explicit flag of a live song. A manual flag is kept
+// when the song is updated; otherwise the flag follows the lyrics.
+func (r *SongRepository) SetExplicit(ctx context.Context, id int, explicit, manual bool) (entities.Song, error) {
+	query := `UPDATE songs SET explicit = $2, explicit_manual = $3 WHERE id = $1`
+	r.logg.WithFields(logrus.Fields{
+		"song_id":  id,
+		"explicit": explicit,
+		"manual":   manual,
+	}).Debug("Executing query to set explicit flag")
+
+	var updated entities.Song
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := r.lockSong(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, id, explicit, manual); err != nil {
+			return err
+		}
+		after, err := r.lockSong(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		updated = *after
+		return r.audit.RecordEvent(ctx, tx, entities.AuditActionUpdate, entities.AuditEntitySong, id, before, after)
+	})
+	if err != nil {
+		if err != sql.ErrNoRows {
+			r.logg.WithError(err).Error("Failed to execute SetExplicit query")
+		}
+		return entities.Song{}, err
+	}
+	return updated, nil
+}
+
+// UpdateExplicitFlags stores automatically detected flags and records each
+// change in the audit log. Songs flagged by hand are left alone. It returns
+// the number of songs changed.
+func (r *SongRepository) UpdateExplicitFlags(ctx context.Context, explicitIDs, cleanIDs []int) (int, error) {
+	query := `SELECT ` + SongColumns + ` FROM songs
+		WHERE (id = ANY($1) OR id = ANY($2)) AND NOT explicit_manual AND explicit <> (id = ANY($1))
+		ORDER BY id FOR UPDATE`
+
+	changed := 0
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, query, pq.Array(explicitIDs), pq.Array(cleanIDs))
+		if err != nil {
+			return err
+		}
+		var before []entities.Song
+		var ids []int
+		for rows.Next() {
+			song, err := scanSong(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			before = append(before, song)
+			ids = append(ids, song.ID)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE songs SET explicit = NOT explicit WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+			return err
+		}
+		for _, song := range before {
+			after := song
+			after.Explicit = !song.Explicit
+			if err := r.audit.RecordEvent(ctx, tx, entities.AuditActionUpdate, entities.AuditEntitySong, song.ID, song, after); err != nil {
+				return err
+			}
+		}
+		changed = len(ids)
+		return nil
+	})
+	if err != nil {
+		r.logg.WithError(err).Error("Failed to execute UpdateExplicitFlags query")
+		return 0, err
+	}
+	return changed, nil
+}
+
+// lockSong reads a song and locks its row until the surrounding transaction
+// ends. It returns sql.ErrNoRows when the song does not exist or its trash
+// state does not match trashed.
+func (r *SongRepository) lockSong(ctx context.Context, tx *sql.Tx, id int, trashed bool) (*entities.Song, error) {
+	query := `SELECT ` + SongColumns + ` FROM songs WHERE id = $1 AND (deleted_at IS NOT NULL) = $2 FOR UPDATE`
+
+	song, err := scanSong(tx.QueryRowContext(ctx, query, id, trashed))
+	if err != nil {
+		return nil, err
+	}
+	return &song, nil
+}
+
+// inTx runs fn in a new transaction, or under a savepoint when ctx already
+// carries one.
+func (r *SongRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return runInTx(ctx, r.db, func(_ context.Context, tx *sql.Tx) error {
+		return fn(tx)
+	})
+}
+
+func scanSong(row rowScanner) (entities.Song, error) {
+	var song entities.Song
+	var released time.Time
+	err := row.Scan(&song.ID, &song.GroupName, &song.SongName, &released, &song.ReleaseDatePrecision, &song.Text, &song.Link,
+		&song.AllowDuplicate, &song.Explicit, &song.ExplicitManual, &song.EnrichedAt, &song.DeletedAt)
+	song.ReleaseDate = releasedate.Format(released, song.ReleaseDatePrecision)
+	return song, err
+}
+
+func translateSongError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == songDedupIndex {
+		return ErrDuplicateSong
+	}
+	return err
+}
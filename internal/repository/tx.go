@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/senyabanana/library-service/internal/logger"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried by a context. depth counts the
+// savepoints opened inside it and keeps their names unique.
+type txState struct {
+	tx    *sql.Tx
+	depth int
+}
+
+// Repositories groups the repositories that take part in a unit of work.
+// They pick up the transaction from the context passed to them, so the ctx
+// given to a WithTx callback must be used for every call.
+type Repositories struct {
+	Songs      SongRepositoryInterface
+	Revisions  RevisionRepositoryInterface
+	Audit      AuditRepositoryInterface
+	Enrichment EnrichmentRepositoryInterface
+}
+
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error
+}
+
+type TxManager struct {
+	db    *sql.DB
+	repos Repositories
+	logg  *logger.Logger
+}
+
+func NewTxManager(db *sql.DB, repos Repositories, logg *logger.Logger) *TxManager {
+	return &TxManager{
+		db:    db,
+		repos: repos,
+		logg:  logg,
+	}
+}
+
+// WithTx runs fn in a transaction that is committed when fn returns nil and
+// rolled back when it returns an error or panics. Called inside another
+// WithTx, fn runs under a savepoint of the outer transaction instead, so a
+// failed inner step can be undone without aborting the whole transaction.
+func (m *TxManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos Repositories) error) error {
+	err := runInTx(ctx, m.db, func(ctx context.Context, _ *sql.Tx) error {
+		return fn(ctx, m.repos)
+	})
+	if err != nil {
+		m.logg.WithError(err).Debug("Transaction rolled back")
+	}
+	return err
+}
+
+// TxFromContext returns the transaction carried by ctx, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// conn returns the transaction carried by ctx, or db outside of one.
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+func runInTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) (err error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return runInSavepoint(ctx, state, fn)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx}), tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func runInSavepoint(ctx context.Context, outer *txState, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	state := &txState{tx: outer.tx, depth: outer.depth + 1}
+	name := "sp_" + strconv.Itoa(state.depth)
+
+	if _, err := state.tx.ExecContext(ctx, `SAVEPOINT `+name); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			state.tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state), state.tx); err != nil {
+		state.tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT `+name)
+		return err
+	}
+	_, err := state.tx.ExecContext(ctx, `RELEASE SAVEPOINT `+name)
+	return err
+}
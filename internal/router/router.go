@@ -4,12 +4,14 @@ import (
 	"net/http"
 
 	_ "github.com/senyabanana/library-service/docs"
+	"github.com/senyabanana/library-service/internal/auth"
 	"github.com/senyabanana/library-service/internal/handlers"
 	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/middleware"
 	"github.com/swaggo/http-swagger"
 )
 
-func SetupRoutes(handler *handlers.SongHandler, logg *logger.Logger) http.Handler {
+func SetupRoutes(handler *handlers.SongHandler, keyHandler *handlers.APIKeyHandler, auditHandler *handlers.AuditHandler, revisionHandler *handlers.RevisionHandler, importHandler *handlers.ImportHandler, lyricsHandler *handlers.LyricsHandler, statsHandler *handlers.StatsHandler, linkHandler *handlers.LinkHandler, enrichmentHandler *handlers.EnrichmentHandler, authMW *middleware.AuthMiddleware, redirectMW *middleware.RedirectMiddleware, idempotencyMW *middleware.IdempotencyMiddleware, rateLimitMW *middleware.RateLimitMiddleware, logg *logger.Logger) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/songs", func(w http.ResponseWriter, r *http.Request) {
@@ -19,7 +21,7 @@ func SetupRoutes(handler *handlers.SongHandler, logg *logger.Logger) http.Handle
 		case http.MethodGet:
 			handler.GetSongs(w, r)
 		case http.MethodPost:
-			handler.AddSong(w, r)
+			authMW.RequireScope(auth.ScopeWrite, handler.AddSong)(w, r)
 		default:
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		}
@@ -29,16 +31,446 @@ func SetupRoutes(handler *handlers.SongHandler, logg *logger.Logger) http.Handle
 		logg.WithField("method", r.Method).Debug("Request received")
 
 		switch r.Method {
+		case http.MethodGet:
+			handler.GetSong(w, r)
 		case http.MethodDelete:
-			handler.DeleteSong(w, r)
+			authMW.RequireScope(auth.ScopeWrite, handler.DeleteSong)(w, r)
 		case http.MethodPut:
-			handler.UpdateSong(w, r)
+			authMW.RequireScope(auth.ScopeWrite, handler.UpdateSong)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/duplicates", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetDuplicateReports(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/merge/preview", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, handler.PreviewMerge)(w, r)
 		default:
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		}
 	})
 
+	mux.HandleFunc("/songs/merge", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, handler.MergeSongs)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/export", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			handler.ExportSongs(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/batch", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, handler.ExecuteBatch)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/import", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, importHandler.StartImport)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/imports/{id}", authMW.RequireScope(auth.ScopeWrite, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			importHandler.GetImportJob(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/songs/{id}/restore", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, handler.RestoreSong)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/trash", authMW.RequireScope(auth.ScopeWrite, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetTrash(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/songs/{id}/revisions", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			revisionHandler.GetRevisions(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/revisions/diff", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			revisionHandler.DiffRevisions(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/revisions/{rev}", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			revisionHandler.GetRevision(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/revisions/{rev}/restore", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, revisionHandler.RestoreRevision)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/lyrics/lrc", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			lyricsHandler.ExportLRC(w, r)
+		case http.MethodPut:
+			authMW.RequireScope(auth.ScopeWrite, lyricsHandler.ImportLRC)(w, r)
+		case http.MethodDelete:
+			authMW.RequireScope(auth.ScopeWrite, lyricsHandler.DeleteSyncedLyrics)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/lyrics/lines", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			lyricsHandler.GetSyncedLyrics(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/lyrics", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			lyricsHandler.GetLyricsVersions(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/lyrics/original", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPut:
+			authMW.RequireScope(auth.ScopeWrite, lyricsHandler.SetOriginalLanguage)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/lyrics/{lang}", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPut:
+			authMW.RequireScope(auth.ScopeWrite, lyricsHandler.SaveTranslation)(w, r)
+		case http.MethodDelete:
+			authMW.RequireScope(auth.ScopeWrite, lyricsHandler.DeleteTranslation)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/text", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			handler.GetSongText(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/text/at", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			lyricsHandler.LineAt(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/explicit", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPut:
+			authMW.RequireScope(auth.ScopeWrite, handler.SetExplicit)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			statsHandler.GetSongStats(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/links", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			linkHandler.GetLinks(w, r)
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, linkHandler.AddLink)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/links/{linkId}", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodDelete:
+			authMW.RequireScope(auth.ScopeWrite, linkHandler.DeleteLink)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/links/{linkId}/primary", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPut:
+			authMW.RequireScope(auth.ScopeWrite, linkHandler.SetPrimaryLink)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/sources", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			enrichmentHandler.GetFieldSources(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/songs/{id}/refresh", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			authMW.RequireScope(auth.ScopeWrite, enrichmentHandler.RefreshSong)(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/enrichment/reviews", authMW.RequireScope(auth.ScopeRead, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			enrichmentHandler.GetReviews(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/enrichment/reviews/{id}/accept", authMW.RequireScope(auth.ScopeWrite, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			enrichmentHandler.AcceptReview(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/enrichment/reviews/{id}/reject", authMW.RequireScope(auth.ScopeWrite, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPost:
+			enrichmentHandler.RejectReview(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/enrichment/limiter", authMW.RequireScope(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			enrichmentHandler.GetLimiterStats(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/stats/lyrics", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			statsHandler.GetCatalogueStats(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api-keys", authMW.RequireScope(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			keyHandler.GetAPIKeys(w, r)
+		case http.MethodPost:
+			keyHandler.CreateAPIKey(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/api-keys/", authMW.RequireScope(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodDelete:
+			keyHandler.RevokeAPIKey(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/api-keys/{id}/quota", authMW.RequireScope(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPut:
+			keyHandler.SetDailyQuota(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/api-keys/{id}/role", authMW.RequireScope(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodPut:
+			keyHandler.AssignRole(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
+	mux.HandleFunc("/roles", func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			keyHandler.GetRoles(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/audit", authMW.RequireScope(auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		logg.WithField("method", r.Method).Debug("Request received")
+
+		switch r.Method {
+		case http.MethodGet:
+			auditHandler.GetEvents(w, r)
+		default:
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
 	mux.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
-	return mux
+	return middleware.RequestID(rateLimitMW.LimitAuth(authMW.Authenticate(rateLimitMW.Limit(redirectMW.Redirect(idempotencyMW.Idempotent(mux))))))
 }
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const apiKeyPrefix = "lsk_"
+
+type APIKeyServiceInterface interface {
+	CreateAPIKey(ctx context.Context, req entities.APIKeyRequest) (entities.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context) ([]entities.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AssignRole(ctx context.Context, id int, role string) (entities.APIKey, error)
+	SetDailyQuota(ctx context.Context, id int, quota *int) (entities.APIKey, error)
+	Authenticate(ctx context.Context, rawKey string) (*auth.Principal, error)
+}
+
+type APIKeyService struct {
+	repo     repository.APIKeyRepositoryInterface
+	adminKey string
+	logg     *logger.Logger
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepositoryInterface, adminKey string, logg *logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo:     repo,
+		adminKey: adminKey,
+		logg:     logg,
+	}
+}
+
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, req entities.APIKeyRequest) (entities.CreatedAPIKey, error) {
+	s.logg.WithFields(logrus.Fields{
+		"name":   req.Name,
+		"scopes": req.Scopes,
+	}).Debug("Creating API key")
+
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return entities.CreatedAPIKey{}, err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return entities.CreatedAPIKey{}, fmt.Errorf("%w: name is required", ErrValidation)
+	}
+	if len(req.Scopes) == 0 {
+		return entities.CreatedAPIKey{}, fmt.Errorf("%w: at least one scope is required", ErrValidation)
+	}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			return entities.CreatedAPIKey{}, fmt.Errorf("%w: unknown scope %q, expected read, write or admin", ErrValidation, scope)
+		}
+	}
+	if req.Role == "" {
+		req.Role = auth.DefaultRole(req.Scopes)
+	} else if !auth.ValidRole(req.Role) {
+		return entities.CreatedAPIKey{}, fmt.Errorf("%w: unknown role %q, expected viewer, editor or admin", ErrValidation, req.Role)
+	} else if !auth.RoleAllowed(req.Role, req.Scopes) {
+		return entities.CreatedAPIKey{}, fmt.Errorf("%w: role %s grants more than scopes %v allow", ErrValidation, req.Role, req.Scopes)
+	}
+	if req.DailyQuota != nil && *req.DailyQuota < 0 {
+		return entities.CreatedAPIKey{}, fmt.Errorf("%w: daily_quota must not be negative", ErrValidation)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return entities.CreatedAPIKey{}, fmt.Errorf("%w: expires_at must be in the future", ErrValidation)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		s.logg.WithError(err).Error("Failed to generate API key")
+		return entities.CreatedAPIKey{}, err
+	}
+	rawKey := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key, err := s.repo.CreateAPIKey(ctx, entities.APIKey{
+		Name:       req.Name,
+		Prefix:     rawKey[:len(apiKeyPrefix)+8],
+		Scopes:     req.Scopes,
+		Role:       req.Role,
+		DailyQuota: req.DailyQuota,
+		ExpiresAt:  req.ExpiresAt,
+	}, hashAPIKey(rawKey))
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to store API key")
+		return entities.CreatedAPIKey{}, err
+	}
+
+	s.logg.WithField("key_id", key.ID).Info("API key created successfully")
+	return entities.CreatedAPIKey{Key: rawKey, APIKey: key}, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context) ([]entities.APIKey, error) {
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.repo.GetAPIKeys(ctx)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch API keys from repository")
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	s.logg.WithField("key_id", id).Debug("Revoking API key")
+
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return err
+	}
+
+	revoked, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to revoke API key in repository")
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("%w: API key %d does not exist or is already revoked", ErrNotFound, id)
+	}
+
+	s.logg.WithField("key_id", id).Info("API key revoked successfully")
+	return nil
+}
+
+func (s *APIKeyService) AssignRole(ctx context.Context, id int, role string) (entities.APIKey, error) {
+	s.logg.WithFields(logrus.Fields{
+		"key_id": id,
+		"role":   role,
+	}).Debug("Assigning role to API key")
+
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return entities.APIKey{}, err
+	}
+	if !auth.ValidRole(role) {
+		return entities.APIKey{}, fmt.Errorf("%w: unknown role %q, expected viewer, editor or admin", ErrValidation, role)
+	}
+
+	key, err := s.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.APIKey{}, fmt.Errorf("%w: API key %d does not exist", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to fetch API key from repository")
+		return entities.APIKey{}, err
+	}
+	if !auth.RoleAllowed(role, key.Scopes) {
+		return entities.APIKey{}, fmt.Errorf("%w: role %s grants more than scopes %v allow", ErrValidation, role, key.Scopes)
+	}
+
+	key, err = s.repo.SetAPIKeyRole(ctx, id, role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.APIKey{}, fmt.Errorf("%w: API key %d does not exist", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to assign role in repository")
+		return entities.APIKey{}, err
+	}
+
+	s.logg.WithField("key_id", id).Info("Role assigned successfully")
+	return key, nil
+}
+
+// SetDailyQuota sets the number of requests a day the key may make; nil
+// restores the default quota and 0 lifts it.
+func (s *APIKeyService) SetDailyQuota(ctx context.Context, id int, quota *int) (entities.APIKey, error) {
+	s.logg.WithField("key_id", id).Debug("Setting API key daily quota")
+
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return entities.APIKey{}, err
+	}
+	if quota != nil && *quota < 0 {
+		return entities.APIKey{}, fmt.Errorf("%w: daily_quota must not be negative", ErrValidation)
+	}
+
+	key, err := s.repo.SetAPIKeyQuota(ctx, id, quota)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.APIKey{}, fmt.Errorf("%w: API key %d does not exist", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to set quota in repository")
+		return entities.APIKey{}, err
+	}
+
+	s.logg.WithField("key_id", id).Info("Daily quota set successfully")
+	return key, nil
+}
+
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*auth.Principal, error) {
+	if s.adminKey != "" && subtle.ConstantTimeCompare([]byte(rawKey), []byte(s.adminKey)) == 1 {
+		noQuota := 0
+		return &auth.Principal{Name: "bootstrap-admin", Scopes: []string{auth.ScopeAdmin}, Role: auth.RoleAdmin, DailyQuota: &noQuota}, nil
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: invalid API key", ErrUnauthorized)
+		}
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: API key has been revoked", ErrUnauthorized)
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, fmt.Errorf("%w: API key has expired", ErrUnauthorized)
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		s.logg.WithError(err).WithField("key_id", key.ID).Warn("Failed to update API key last-used timestamp")
+	}
+
+	return &auth.Principal{KeyID: key.ID, Name: key.Name, Scopes: key.Scopes, Role: key.Role, DailyQuota: key.DailyQuota}, nil
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type AuditServiceInterface interface {
+	GetEvents(ctx context.Context, filters entities.AuditFilters, pagination entities.Pagination) ([]entities.AuditEvent, error)
+}
+
+type AuditService struct {
+	repo repository.AuditRepositoryInterface
+	logg *logger.Logger
+}
+
+func NewAuditService(repo repository.AuditRepositoryInterface, logg *logger.Logger) *AuditService {
+	return &AuditService{
+		repo: repo,
+		logg: logg,
+	}
+}
+
+func (s *AuditService) GetEvents(ctx context.Context, filters entities.AuditFilters, pagination entities.Pagination) ([]entities.AuditEvent, error) {
+	s.logg.WithFields(logrus.Fields{
+		"filters":    filters,
+		"pagination": pagination,
+	}).Debug("Fetching audit events with filters")
+
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, actor, actor_key_id, action, entity_type, entity_id, before, after, diff, request_id, created_at
+		FROM audit_events WHERE 1=1`
+	args := []interface{}{}
+	argIndex := 1
+
+	addFilter := func(clause string, value interface{}) {
+		query += ` AND ` + clause + ` $` + strconv.Itoa(argIndex)
+		args = append(args, value)
+		argIndex++
+	}
+
+	if filters.Actor != "" {
+		addFilter("actor =", filters.Actor)
+	}
+	if filters.Action != "" {
+		addFilter("action =", filters.Action)
+	}
+	if filters.EntityType != "" {
+		addFilter("entity_type =", filters.EntityType)
+	}
+	if filters.EntityID != 0 {
+		addFilter("entity_id =", filters.EntityID)
+	}
+	if filters.RequestID != "" {
+		addFilter("request_id =", filters.RequestID)
+	}
+	if filters.From != nil {
+		addFilter("created_at >=", *filters.From)
+	}
+	if filters.To != nil {
+		addFilter("created_at <", *filters.To)
+	}
+
+	offset := (pagination.Page - 1) * pagination.PerPage
+	query += ` ORDER BY id DESC LIMIT $` + strconv.Itoa(argIndex) + ` OFFSET $` + strconv.Itoa(argIndex+1)
+	args = append(args, pagination.PerPage, offset)
+
+	events, err := s.repo.GetEventsWithQuery(ctx, query, args...)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch audit events from repository")
+		return nil, err
+	}
+
+	s.logg.WithField("count", len(events)).Info("Audit events fetched successfully")
+	return events, nil
+}
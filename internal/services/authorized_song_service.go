@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"io"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+)
+
+// AuthorizedSongService enforces role permissions in front of another
+// SongServiceInterface implementation.
+type AuthorizedSongService struct {
+	next SongServiceInterface
+	logg *logger.Logger
+}
+
+func NewAuthorizedSongService(next SongServiceInterface, logg *logger.Logger) *AuthorizedSongService {
+	return &AuthorizedSongService{
+		next: next,
+		logg: logg,
+	}
+}
+
+func (s *AuthorizedSongService) AddSong(ctx context.Context, song entities.Song) (entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsWrite); err != nil {
+		return entities.Song{}, err
+	}
+	return s.next.AddSong(ctx, song)
+}
+
+func (s *AuthorizedSongService) GetSong(ctx context.Context, id int) (entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsRead); err != nil {
+		return entities.Song{}, err
+	}
+	return s.next.GetSong(ctx, id)
+}
+
+func (s *AuthorizedSongService) GetSongs(ctx context.Context, filters entities.SongFilters, pagination entities.Pagination) ([]entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsRead); err != nil {
+		return nil, err
+	}
+	return s.next.GetSongs(ctx, filters, pagination)
+}
+
+func (s *AuthorizedSongService) ExportSongs(ctx context.Context, filters entities.SongFilters, format string, columns []string, w io.Writer) error {
+	if err := s.authorize(ctx, auth.PermSongsRead); err != nil {
+		return err
+	}
+	return s.next.ExportSongs(ctx, filters, format, columns, w)
+}
+
+func (s *AuthorizedSongService) GetSongText(ctx context.Context, id int, opts entities.TextOptions) (entities.SongText, error) {
+	if err := s.authorize(ctx, auth.PermSongsRead); err != nil {
+		return entities.SongText{}, err
+	}
+	return s.next.GetSongText(ctx, id, opts)
+}
+
+func (s *AuthorizedSongService) UpdateSong(ctx context.Context, song entities.Song) (entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsWrite); err != nil {
+		return entities.Song{}, err
+	}
+	return s.next.UpdateSong(ctx, song)
+}
+
+func (s *AuthorizedSongService) ApplyEnrichment(ctx context.Context, id int, details entities.Details, sources []entities.FieldSource) (entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsWrite); err != nil {
+		return entities.Song{}, err
+	}
+	return s.next.ApplyEnrichment(ctx, id, details, sources)
+}
+
+func (s *AuthorizedSongService) SetExplicit(ctx context.Context, id int, explicit *bool) (entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsWrite); err != nil {
+		return entities.Song{}, err
+	}
+	return s.next.SetExplicit(ctx, id, explicit)
+}
+
+func (s *AuthorizedSongService) DeleteSong(ctx context.Context, id int) error {
+	if err := s.authorize(ctx, auth.PermSongsDelete); err != nil {
+		return err
+	}
+	return s.next.DeleteSong(ctx, id)
+}
+
+func (s *AuthorizedSongService) RestoreSong(ctx context.Context, id int) error {
+	if err := s.authorize(ctx, auth.PermSongsRestore); err != nil {
+		return err
+	}
+	return s.next.RestoreSong(ctx, id)
+}
+
+func (s *AuthorizedSongService) HardDeleteSong(ctx context.Context, id int) error {
+	if err := s.authorize(ctx, auth.PermSongsPurge); err != nil {
+		return err
+	}
+	return s.next.HardDeleteSong(ctx, id)
+}
+
+func (s *AuthorizedSongService) GetTrash(ctx context.Context, pagination entities.Pagination) ([]entities.Song, error) {
+	if err := s.authorize(ctx, auth.PermSongsRestore); err != nil {
+		return nil, err
+	}
+	return s.next.GetTrash(ctx, pagination)
+}
+
+func (s *AuthorizedSongService) GetDuplicateReports(ctx context.Context) ([]entities.DuplicateReport, error) {
+	if err := s.authorize(ctx, auth.PermSongsRead); err != nil {
+		return nil, err
+	}
+	return s.next.GetDuplicateReports(ctx)
+}
+
+func (s *AuthorizedSongService) PreviewMerge(ctx context.Context, req entities.MergeRequest) (entities.MergePreview, error) {
+	if err := s.authorize(ctx, auth.PermSongsMerge); err != nil {
+		return entities.MergePreview{}, err
+	}
+	return s.next.PreviewMerge(ctx, req)
+}
+
+func (s *AuthorizedSongService) MergeSongs(ctx context.Context, req entities.MergeRequest) (entities.MergePreview, error) {
+	if err := s.authorize(ctx, auth.PermSongsMerge); err != nil {
+		return entities.MergePreview{}, err
+	}
+	return s.next.MergeSongs(ctx, req)
+}
+
+// ExecuteBatch checks the permissions of every operation up front, so a batch
+// is rejected as a whole rather than failing halfway through.
+func (s *AuthorizedSongService) ExecuteBatch(ctx context.Context, req entities.BatchRequest) (entities.BatchResponse, error) {
+	for _, op := range req.Operations {
+		perm := auth.PermSongsWrite
+		if op.Op == entities.BatchOpDelete {
+			perm = auth.PermSongsDelete
+		}
+		if err := s.authorize(ctx, perm); err != nil {
+			return entities.BatchResponse{}, err
+		}
+	}
+	return s.next.ExecuteBatch(ctx, req)
+}
+
+// ResolveSongID only routes requests to the right song, so it needs no
+// permission of its own.
+func (s *AuthorizedSongService) ResolveSongID(ctx context.Context, id int) (int, bool, error) {
+	return s.next.ResolveSongID(ctx, id)
+}
+
+func (s *AuthorizedSongService) authorize(ctx context.Context, perm auth.Permission) error {
+	if err := auth.Require(ctx, perm); err != nil {
+		s.logg.WithError(err).Warn("Permission denied")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/api"
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/enrichment"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/lyrics"
+	"github.com/senyabanana/library-service/internal/releasedate"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type EnrichmentServiceInterface interface {
+	GetFieldSources(ctx context.Context, songID int) ([]entities.FieldSource, error)
+	RefreshSong(ctx context.Context, songID int) (entities.RefreshResult, error)
+	GetReviews(ctx context.Context, status string, pagination entities.Pagination) ([]entities.EnrichmentReview, error)
+	AcceptReview(ctx context.Context, id int) (entities.EnrichmentReview, error)
+	RejectReview(ctx context.Context, id int) (entities.EnrichmentReview, error)
+	GetLimiterStats(ctx context.Context) (entities.LimiterStats, error)
+}
+
+type EnrichmentService struct {
+	repo     repository.EnrichmentRepositoryInterface
+	songs    SongServiceInterface
+	enricher *enrichment.Pipeline
+	limiter  *api.Limiter
+	logg     *logger.Logger
+}
+
+func NewEnrichmentService(repo repository.EnrichmentRepositoryInterface, songs SongServiceInterface, enricher *enrichment.Pipeline, limiter *api.Limiter, logg *logger.Logger) *EnrichmentService {
+	return &EnrichmentService{
+		repo:     repo,
+		songs:    songs,
+		enricher: enricher,
+		limiter:  limiter,
+		logg:     logg,
+	}
+}
+
+// GetFieldSources returns the provider each field of a song came from, or
+// "manual" for fields given or edited by hand.
+func (s *EnrichmentService) GetFieldSources(ctx context.Context, songID int) ([]entities.FieldSource, error) {
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+
+	sources, err := s.repo.GetFieldSources(ctx, songID)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch field sources from repository")
+		return nil, err
+	}
+	return sources, nil
+}
+
+// RefreshSong looks a song up again and updates the fields that came from a
+// provider and have changed upstream. Upstream values for fields edited by
+// hand are put up for review instead. Values from the placeholder provider
+// never replace anything, and fields without a source are left alone. A
+// failed refresh puts off the next scheduled one.
+func (s *EnrichmentService) RefreshSong(ctx context.Context, songID int) (entities.RefreshResult, error) {
+	s.logg.WithField("song_id", songID).Debug("Refreshing song enrichment")
+
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.RefreshResult{}, err
+	}
+	song, err := s.songs.GetSong(ctx, songID)
+	if err != nil {
+		return entities.RefreshResult{}, err
+	}
+
+	result, err := s.refreshSong(ctx, song)
+	if err != nil {
+		if err := s.repo.MarkEnrichmentFailed(ctx, songID); err != nil {
+			s.logg.WithError(err).WithField("song_id", songID).Error("Failed to record refresh failure")
+		}
+		return entities.RefreshResult{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"updated": result.Updated,
+		"reviews": len(result.Reviews),
+	}).Info("Song enrichment refreshed")
+	return result, nil
+}
+
+func (s *EnrichmentService) refreshSong(ctx context.Context, song entities.Song) (entities.RefreshResult, error) {
+	songID := song.ID
+	sources, err := s.repo.GetFieldSources(ctx, songID)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch field sources from repository")
+		return entities.RefreshResult{}, err
+	}
+
+	result := entities.RefreshResult{SongID: songID, Updated: []string{}, Reviews: []entities.EnrichmentReview{}}
+	tracked := map[string]string{}
+	var fields []string
+	for _, source := range sources {
+		tracked[source.Field] = source.Provider
+		fields = append(fields, source.Field)
+	}
+	if len(fields) == 0 {
+		return result, nil
+	}
+
+	fetched, err := s.enricher.Enrich(ctx, song.GroupName, song.SongName, fields...)
+	if err != nil && !errors.Is(err, enrichment.ErrNotFound) {
+		s.logg.WithError(err).WithField("song_id", songID).Error("Failed to refresh song enrichment")
+		return entities.RefreshResult{}, err
+	}
+
+	var apply []entities.FieldSource
+	for _, source := range fetched.Sources {
+		if source.Provider == enrichment.ProviderPlaceholder {
+			continue
+		}
+		current := songField(song, source.Field)
+		proposed, ok := normalizeField(source.Field, enrichedField(fetched.Details, source.Field))
+		if !ok {
+			s.logg.WithFields(logrus.Fields{
+				"song_id":  songID,
+				"field":    source.Field,
+				"provider": source.Provider,
+			}).Warn("Ignoring invalid upstream value")
+			continue
+		}
+		if proposed == current {
+			continue
+		}
+
+		if tracked[source.Field] != entities.SourceManual {
+			apply = append(apply, source)
+			continue
+		}
+		review, opened, err := s.repo.SaveReview(ctx, entities.EnrichmentReview{
+			SongID:        songID,
+			Field:         source.Field,
+			CurrentValue:  current,
+			ProposedValue: proposed,
+			Provider:      source.Provider,
+		})
+		if err != nil {
+			s.logg.WithError(err).Error("Failed to save enrichment review")
+			return entities.RefreshResult{}, err
+		}
+		if opened {
+			result.Reviews = append(result.Reviews, review)
+		}
+	}
+
+	if len(apply) > 0 {
+		if _, err := s.songs.ApplyEnrichment(ctx, songID, fetched.Details, apply); err != nil {
+			s.logg.WithError(err).WithField("song_id", songID).Error("Failed to apply refreshed enrichment")
+			return entities.RefreshResult{}, err
+		}
+		for _, source := range apply {
+			result.Updated = append(result.Updated, source.Field)
+		}
+	} else if err := s.repo.MarkEnriched(ctx, songID, time.Now()); err != nil {
+		return entities.RefreshResult{}, err
+	}
+	return result, nil
+}
+
+func (s *EnrichmentService) GetReviews(ctx context.Context, status string, pagination entities.Pagination) ([]entities.EnrichmentReview, error) {
+	if err := auth.Require(ctx, auth.PermSongsRead); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", entities.ReviewStatusPending, entities.ReviewStatusAccepted, entities.ReviewStatusRejected:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q, expected pending, accepted or rejected", ErrValidation, status)
+	}
+
+	reviews, err := s.repo.GetReviews(ctx, status, pagination)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch enrichment reviews from repository")
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// AcceptReview applies the proposed value; the field is then tracked as
+// coming from the provider again.
+func (s *EnrichmentService) AcceptReview(ctx context.Context, id int) (entities.EnrichmentReview, error) {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.EnrichmentReview{}, err
+	}
+	review, err := s.pendingReview(ctx, id)
+	if err != nil {
+		return entities.EnrichmentReview{}, err
+	}
+
+	var details entities.Details
+	setEnrichedField(&details, review.Field, review.ProposedValue)
+	source := entities.FieldSource{Field: review.Field, Provider: review.Provider, EnrichedAt: time.Now()}
+	if _, err := s.songs.ApplyEnrichment(ctx, review.SongID, details, []entities.FieldSource{source}); err != nil {
+		return entities.EnrichmentReview{}, err
+	}
+	return s.resolve(ctx, id, entities.ReviewStatusAccepted)
+}
+
+// RejectReview keeps the manual value. The same upstream value is not put
+// up for review again.
+func (s *EnrichmentService) RejectReview(ctx context.Context, id int) (entities.EnrichmentReview, error) {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.EnrichmentReview{}, err
+	}
+	return s.resolve(ctx, id, entities.ReviewStatusRejected)
+}
+
+func (s *EnrichmentService) pendingReview(ctx context.Context, id int) (entities.EnrichmentReview, error) {
+	review, err := s.repo.GetReview(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.EnrichmentReview{}, fmt.Errorf("%w: enrichment review %d", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to fetch enrichment review from repository")
+		return entities.EnrichmentReview{}, err
+	}
+	if review.Status != entities.ReviewStatusPending {
+		return entities.EnrichmentReview{}, fmt.Errorf("%w: enrichment review %d is already %s", ErrValidation, id, review.Status)
+	}
+	return review, nil
+}
+
+func (s *EnrichmentService) resolve(ctx context.Context, id int, status string) (entities.EnrichmentReview, error) {
+	review, err := s.repo.ResolveReview(ctx, id, status, auth.ActorName(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.EnrichmentReview{}, fmt.Errorf("%w: pending enrichment review %d", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to resolve enrichment review")
+		return entities.EnrichmentReview{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"review_id": id,
+		"song_id":   review.SongID,
+		"status":    status,
+	}).Info("Enrichment review resolved")
+	return review, nil
+}
+
+func songField(song entities.Song, field string) string {
+	switch field {
+	case enrichment.FieldReleaseDate:
+		return song.ReleaseDate
+	case enrichment.FieldText:
+		return song.Text
+	case enrichment.FieldLink:
+		return song.Link
+	}
+	return ""
+}
+
+func enrichedField(details entities.Details, field string) string {
+	switch field {
+	case enrichment.FieldReleaseDate:
+		return details.ReleaseDate
+	case enrichment.FieldText:
+		return details.Text
+	case enrichment.FieldLink:
+		return details.Link
+	}
+	return ""
+}
+
+func setEnrichedField(details *entities.Details, field, value string) {
+	switch field {
+	case enrichment.FieldReleaseDate:
+		details.ReleaseDate = value
+	case enrichment.FieldText:
+		details.Text = value
+	case enrichment.FieldLink:
+		details.Link = value
+	}
+}
+
+// normalizeField brings an upstream value into the form the song stores, so
+// that it can be compared with the current value.
+func normalizeField(field, value string) (string, bool) {
+	switch field {
+	case enrichment.FieldReleaseDate:
+		normalized, err := releasedate.Normalize(value)
+		return normalized, err == nil
+	case enrichment.FieldText:
+		return lyrics.NormalizeText(strings.TrimSpace(value)), true
+	case enrichment.FieldLink:
+		normalized, err := links.Canonicalize(value)
+		return normalized, err == nil
+	}
+	return "", false
+}
+
+// GetLimiterStats reports the limiter in front of the external music API.
+func (s *EnrichmentService) GetLimiterStats(ctx context.Context) (entities.LimiterStats, error) {
+	if err := auth.Require(ctx, auth.PermAdmin); err != nil {
+		return entities.LimiterStats{}, err
+	}
+	return s.limiter.Stats(), nil
+}
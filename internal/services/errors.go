@@ -0,0 +1,24 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// DuplicateError reports that a song collides with an existing live song.
+type DuplicateError struct {
+	ExistingID int
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("song already exists with id %d; set allow_duplicate to add another version", e.ExistingID)
+}
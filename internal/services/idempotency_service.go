@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type IdempotencyServiceInterface interface {
+	Begin(ctx context.Context, key, fingerprint string) (*entities.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, status int, header map[string][]string, body []byte) error
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyService struct {
+	repo repository.IdempotencyRepositoryInterface
+	ttl  time.Duration
+	logg *logger.Logger
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepositoryInterface, ttl time.Duration, logg *logger.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		ttl:  ttl,
+		logg: logg,
+	}
+}
+
+// Begin reserves key for the caller in ctx. It returns nil when the request
+// should be processed, or the stored record when an identical request has
+// already completed and its response should be replayed.
+func (s *IdempotencyService) Begin(ctx context.Context, key, fingerprint string) (*entities.IdempotencyRecord, error) {
+	scope := idempotencyScope(ctx)
+	s.logg.WithFields(logrus.Fields{
+		"scope": scope,
+		"key":   key,
+	}).Debug("Checking idempotency key")
+
+	record, reserved, err := s.repo.ReserveKey(ctx, entities.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to reserve idempotency key")
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if record.Fingerprint != fingerprint {
+		s.logg.WithField("key", key).Warn("Idempotency key reused with a different request")
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.StatusCode == 0 {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	s.logg.WithField("key", key).Info("Replaying stored response for idempotency key")
+	return &record, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, key string, status int, header map[string][]string, body []byte) error {
+	err := s.repo.CompleteKey(ctx, entities.IdempotencyRecord{
+		Scope:      idempotencyScope(ctx),
+		Key:        key,
+		StatusCode: status,
+		Header:     header,
+		Body:       body,
+	})
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to store idempotent response")
+	}
+	return err
+}
+
+// Release forgets key, so that a request which failed on the server side can
+// be retried with it.
+func (s *IdempotencyService) Release(ctx context.Context, key string) error {
+	if err := s.repo.ReleaseKey(ctx, idempotencyScope(ctx), key); err != nil {
+		s.logg.WithError(err).Error("Failed to release idempotency key")
+		return err
+	}
+	return nil
+}
+
+// idempotencyScope keeps keys of different clients apart.
+func idempotencyScope(ctx context.Context) string {
+	p, ok := auth.PrincipalFromContext(ctx)
+	switch {
+	case !ok:
+		return "anonymous"
+	case p.KeyID > 0:
+		return "key:" + strconv.Itoa(p.KeyID)
+	default:
+		return "principal:" + p.Name
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/lyrics"
+	"github.com/senyabanana/library-service/internal/profanity"
+	"github.com/senyabanana/library-service/internal/releasedate"
+	"github.com/senyabanana/library-service/internal/repository"
+	"github.com/senyabanana/library-service/internal/songio"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	importBatchSize = 1000
+	maxImportErrors = 1000
+)
+
+type ImportServiceInterface interface {
+	StartImport(ctx context.Context, format string, dryRun bool, body io.Reader) (entities.ImportJob, error)
+	GetImportJob(ctx context.Context, id int) (entities.ImportJob, error)
+}
+
+type ImportService struct {
+	repo      repository.ImportRepositoryInterface
+	stats     *LyricsStatsCache
+	profanity *profanity.Dictionary
+	logg      *logger.Logger
+}
+
+func NewImportService(repo repository.ImportRepositoryInterface, stats *LyricsStatsCache, dictionary *profanity.Dictionary, logg *logger.Logger) *ImportService {
+	return &ImportService{
+		repo:      repo,
+		stats:     stats,
+		profanity: dictionary,
+		logg:      logg,
+	}
+}
+
+// StartImport spools the upload to a temporary file, so the request does not
+// have to stay open and nothing is held in memory, and processes it in the
+// background. Progress is reported through the returned job.
+func (s *ImportService) StartImport(ctx context.Context, format string, dryRun bool, body io.Reader) (entities.ImportJob, error) {
+	s.logg.WithFields(logrus.Fields{
+		"format":  format,
+		"dry_run": dryRun,
+	}).Debug("Starting import")
+
+	if err := auth.Require(ctx, auth.PermSongsImport); err != nil {
+		return entities.ImportJob{}, err
+	}
+	if format != songio.FormatCSV && format != songio.FormatNDJSON {
+		return entities.ImportJob{}, fmt.Errorf("%w: unsupported format %q, expected csv or ndjson", ErrValidation, format)
+	}
+
+	file, err := os.CreateTemp("", "song-import-*")
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to create import spool file")
+		return entities.ImportJob{}, err
+	}
+	size, err := io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		s.logg.WithError(err).Error("Failed to spool import upload")
+		return entities.ImportJob{}, err
+	}
+
+	job, err := s.repo.CreateImportJob(ctx, entities.ImportJob{
+		Format:     format,
+		DryRun:     dryRun,
+		Status:     entities.ImportStatusPending,
+		BytesTotal: size,
+		CreatedBy:  auth.ActorName(ctx),
+	})
+	if err != nil {
+		os.Remove(file.Name())
+		s.logg.WithError(err).Error("Failed to create import job")
+		return entities.ImportJob{}, err
+	}
+
+	go s.runImport(context.WithoutCancel(ctx), job, file.Name())
+
+	s.logg.WithField("job_id", job.ID).Info("Import job started")
+	return job, nil
+}
+
+func (s *ImportService) GetImportJob(ctx context.Context, id int) (entities.ImportJob, error) {
+	if err := auth.Require(ctx, auth.PermSongsImport); err != nil {
+		return entities.ImportJob{}, err
+	}
+
+	job, err := s.repo.GetImportJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.ImportJob{}, fmt.Errorf("%w: import job %d", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to fetch import job from repository")
+		return entities.ImportJob{}, err
+	}
+	return job, nil
+}
+
+func (s *ImportService) runImport(ctx context.Context, job entities.ImportJob, path string) {
+	defer os.Remove(path)
+	logg := s.logg.WithField("job_id", job.ID)
+
+	now := time.Now()
+	job.Status = entities.ImportStatusRunning
+	job.StartedAt = &now
+	job.Errors = []entities.ImportRowError{}
+	s.saveProgress(ctx, job)
+
+	err := s.processImport(ctx, &job, path)
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = entities.ImportStatusCompleted
+	if err != nil {
+		logg.WithError(err).Error("Import job failed")
+		job.Status = entities.ImportStatusFailed
+		job.Error = err.Error()
+	}
+	s.saveProgress(ctx, job)
+	if job.RowsInserted > 0 && !job.DryRun {
+		s.stats.InvalidateCatalogue()
+	}
+
+	logg.WithFields(logrus.Fields{
+		"status":   job.Status,
+		"inserted": job.RowsInserted,
+		"failed":   job.RowsFailed,
+	}).Info("Import job finished")
+}
+
+func (s *ImportService) processImport(ctx context.Context, job *entities.ImportJob, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	counter := &countingReader{r: file}
+
+	batch := make([]entities.ImportRow, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) > 0 {
+			duplicates, inserted, err := s.repo.ImportSongs(ctx, job.ID, batch, job.DryRun)
+			if err != nil {
+				return fmt.Errorf("failed to import rows %d-%d: %w", batch[0].Row, batch[len(batch)-1].Row, err)
+			}
+			job.RowsInserted += inserted
+			for _, row := range duplicates {
+				addImportError(job, row, "duplicate of an existing song or an earlier row; set allow_duplicate to import anyway")
+			}
+			batch = batch[:0]
+		}
+		job.BytesProcessed = counter.n
+		s.saveProgress(ctx, *job)
+		return nil
+	}
+
+	err = songio.Read(counter, job.Format, func(row int, song entities.Song, err error) error {
+		job.RowsProcessed++
+		if err == nil {
+			err = validateImportRow(&song)
+		}
+		if err != nil {
+			addImportError(job, row, err.Error())
+			return nil
+		}
+
+		song.Text = lyrics.NormalizeText(song.Text)
+		// A song flagged explicit in the file stays explicit; the dictionary
+		// can only add the flag.
+		song.Explicit = song.Explicit || s.profanity.Explicit(song.Text, "")
+		batch = append(batch, entities.ImportRow{Row: row, Song: song})
+		if len(batch) == importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+func (s *ImportService) saveProgress(ctx context.Context, job entities.ImportJob) {
+	if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+		s.logg.WithError(err).WithField("job_id", job.ID).Error("Failed to save import progress")
+	}
+}
+
+// validateImportRow checks a row and normalizes its release date.
+func validateImportRow(song *entities.Song) error {
+	if song.GroupName == "" || song.SongName == "" {
+		return errors.New("group and song are required")
+	}
+	if utf8.RuneCountInString(song.GroupName) > 255 || utf8.RuneCountInString(song.SongName) > 255 {
+		return errors.New("group and song must be at most 255 characters")
+	}
+	released, err := releasedate.Parse(song.ReleaseDate)
+	if err != nil {
+		return err
+	}
+	song.ReleaseDate, song.ReleaseDatePrecision = released.String(), released.Precision
+	if song.Link != "" {
+		if song.Link, err = links.Canonicalize(song.Link); err != nil {
+			return fmt.Errorf("link: %w", err)
+		}
+	}
+	return nil
+}
+
+// addImportError counts a failed row and keeps its error for the report,
+// up to maxImportErrors entries.
+func addImportError(job *entities.ImportJob, row int, message string) {
+	job.RowsFailed++
+	if len(job.Errors) < maxImportErrors {
+		job.Errors = append(job.Errors, entities.ImportRowError{Row: row, Error: message})
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LinkServiceInterface interface {
+	GetLinks(ctx context.Context, songID int) ([]entities.SongLink, error)
+	AddLink(ctx context.Context, songID int, req entities.SongLinkRequest) (entities.SongLink, error)
+	DeleteLink(ctx context.Context, songID, linkID int) error
+	SetPrimaryLink(ctx context.Context, songID, linkID int) (entities.SongLink, error)
+}
+
+type LinkService struct {
+	repo  repository.LinkRepositoryInterface
+	songs SongServiceInterface
+	logg  *logger.Logger
+}
+
+// NewLinkService looks songs up through songs, so trashed songs and read
+// permissions are handled the same way as for the song itself.
+func NewLinkService(repo repository.LinkRepositoryInterface, songs SongServiceInterface, logg *logger.Logger) *LinkService {
+	return &LinkService{
+		repo:  repo,
+		songs: songs,
+		logg:  logg,
+	}
+}
+
+func (s *LinkService) GetLinks(ctx context.Context, songID int) ([]entities.SongLink, error) {
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+
+	songLinks, err := s.repo.GetLinks(ctx, songID)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch song links from repository")
+		return nil, err
+	}
+	return songLinks, nil
+}
+
+// AddLink stores the link in canonical form, so the same link shared from
+// different places is only added once.
+func (s *LinkService) AddLink(ctx context.Context, songID int, req entities.SongLinkRequest) (entities.SongLink, error) {
+	s.logg.WithField("song_id", songID).Debug("Adding song link")
+
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.SongLink{}, err
+	}
+	url, err := links.Canonicalize(req.URL)
+	if err != nil {
+		return entities.SongLink{}, fmt.Errorf("%w: url %q is not an absolute http or https URL", ErrValidation, req.URL)
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return entities.SongLink{}, err
+	}
+
+	added, err := s.repo.AddLink(ctx, entities.SongLink{SongID: songID, URL: url, Primary: req.Primary})
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkExists) {
+			return entities.SongLink{}, fmt.Errorf("%w: song %d already has link %s", ErrValidation, songID, url)
+		}
+		s.logg.WithError(err).Error("Failed to add song link")
+		return entities.SongLink{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"link_id":  added.ID,
+		"provider": added.Provider,
+	}).Info("Song link added successfully")
+	return added, nil
+}
+
+func (s *LinkService) DeleteLink(ctx context.Context, songID, linkID int) error {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return err
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteLink(ctx, songID, linkID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: song %d has no link %d", ErrNotFound, songID, linkID)
+		}
+		s.logg.WithError(err).Error("Failed to delete song link")
+		return err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"link_id": linkID,
+	}).Info("Song link deleted successfully")
+	return nil
+}
+
+func (s *LinkService) SetPrimaryLink(ctx context.Context, songID, linkID int) (entities.SongLink, error) {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.SongLink{}, err
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return entities.SongLink{}, err
+	}
+
+	link, err := s.repo.SetPrimaryLink(ctx, songID, linkID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.SongLink{}, fmt.Errorf("%w: song %d has no link %d", ErrNotFound, songID, linkID)
+		}
+		s.logg.WithError(err).Error("Failed to set primary song link")
+		return entities.SongLink{}, err
+	}
+	return link, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/lyrics"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+)
+
+type LyricsServiceInterface interface {
+	GetSyncedLyrics(ctx context.Context, songID int) (entities.SyncedLyrics, error)
+	ImportLRC(ctx context.Context, songID int, lrc string) (entities.SyncedLyrics, error)
+	ExportLRC(ctx context.Context, songID int, enhanced bool) (string, error)
+	DeleteSyncedLyrics(ctx context.Context, songID int) error
+	LineAt(ctx context.Context, songID int, positionMS int64) (entities.ActiveLyricLine, error)
+	GetLyricsVersions(ctx context.Context, songID int) ([]entities.SongLyrics, error)
+	SaveTranslation(ctx context.Context, songID int, language, text string) (entities.SongLyrics, error)
+	DeleteTranslation(ctx context.Context, songID int, language string) error
+	SetOriginalLanguage(ctx context.Context, songID int, language string) (entities.SongLyrics, error)
+}
+
+type LyricsService struct {
+	repo  repository.LyricsRepositoryInterface
+	songs SongServiceInterface
+	logg  *logger.Logger
+}
+
+// NewLyricsService looks songs up through songs, so trashed songs and read
+// permissions are handled the same way as for the song itself.
+func NewLyricsService(repo repository.LyricsRepositoryInterface, songs SongServiceInterface, logg *logger.Logger) *LyricsService {
+	return &LyricsService{
+		repo:  repo,
+		songs: songs,
+		logg:  logg,
+	}
+}
+
+func (s *LyricsService) GetSyncedLyrics(ctx context.Context, songID int) (entities.SyncedLyrics, error) {
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+
+	synced, err := s.repo.GetSyncedLyrics(ctx, songID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.SyncedLyrics{}, fmt.Errorf("%w: song %d has no synced lyrics", ErrNotFound, songID)
+		}
+		s.logg.WithError(err).Error("Failed to fetch synced lyrics from repository")
+		return entities.SyncedLyrics{}, err
+	}
+	return synced, nil
+}
+
+func (s *LyricsService) ImportLRC(ctx context.Context, songID int, lrc string) (entities.SyncedLyrics, error) {
+	s.logg.WithField("song_id", songID).Debug("Importing LRC lyrics")
+
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return entities.SyncedLyrics{}, err
+	}
+
+	synced, err := lyrics.ParseLRC(lrc)
+	if err != nil {
+		return entities.SyncedLyrics{}, fmt.Errorf("%w: invalid LRC: %v", ErrValidation, err)
+	}
+	synced.SongID = songID
+
+	saved, err := s.repo.SaveSyncedLyrics(ctx, synced)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to save synced lyrics")
+		return entities.SyncedLyrics{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"lines":   len(saved.Lines),
+	}).Info("LRC lyrics imported successfully")
+	return saved, nil
+}
+
+func (s *LyricsService) ExportLRC(ctx context.Context, songID int, enhanced bool) (string, error) {
+	synced, err := s.GetSyncedLyrics(ctx, songID)
+	if err != nil {
+		return "", err
+	}
+	return lyrics.FormatLRC(synced, enhanced), nil
+}
+
+func (s *LyricsService) DeleteSyncedLyrics(ctx context.Context, songID int) error {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return err
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteSyncedLyrics(ctx, songID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: song %d has no synced lyrics", ErrNotFound, songID)
+		}
+		s.logg.WithError(err).Error("Failed to delete synced lyrics")
+		return err
+	}
+
+	s.logg.WithField("song_id", songID).Info("Synced lyrics deleted successfully")
+	return nil
+}
+
+// LineAt returns the line shown at a playback position. Before the first
+// line and after the end of the last one, no line is active.
+func (s *LyricsService) LineAt(ctx context.Context, songID int, positionMS int64) (entities.ActiveLyricLine, error) {
+	synced, err := s.GetSyncedLyrics(ctx, songID)
+	if err != nil {
+		return entities.ActiveLyricLine{}, err
+	}
+
+	index, ok := lyrics.LineAt(synced.Lines, positionMS)
+	if !ok {
+		return entities.ActiveLyricLine{}, fmt.Errorf("%w: no line is active at %d ms", ErrNotFound, positionMS)
+	}
+	return entities.ActiveLyricLine{Index: index, LyricLine: synced.Lines[index]}, nil
+}
+
+func (s *LyricsService) GetLyricsVersions(ctx context.Context, songID int) ([]entities.SongLyrics, error) {
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.repo.GetLyricsVersions(ctx, songID)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch lyrics versions from repository")
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (s *LyricsService) SaveTranslation(ctx context.Context, songID int, language, text string) (entities.SongLyrics, error) {
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"language": language,
+	}).Debug("Saving translation")
+
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.SongLyrics{}, err
+	}
+	lang, err := normalizeLanguage(language)
+	if err != nil {
+		return entities.SongLyrics{}, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return entities.SongLyrics{}, fmt.Errorf("%w: text is required", ErrValidation)
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return entities.SongLyrics{}, err
+	}
+
+	text = lyrics.NormalizeText(text)
+	saved, err := s.repo.SaveTranslation(ctx, entities.SongLyrics{
+		SongID:   songID,
+		Language: lang,
+		Text:     text,
+		Sections: lyrics.ParseSections(text),
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrOriginalLyrics) {
+			return entities.SongLyrics{}, fmt.Errorf("%w: %s is the original language; edit the original lyrics through PUT /songs/%d", ErrValidation, lang, songID)
+		}
+		s.logg.WithError(err).Error("Failed to save translation")
+		return entities.SongLyrics{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"language": lang,
+	}).Info("Translation saved successfully")
+	return saved, nil
+}
+
+func (s *LyricsService) DeleteTranslation(ctx context.Context, songID int, language string) error {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return err
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return err
+	}
+	lang, err := normalizeLanguage(language)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTranslation(ctx, songID, lang); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: song %d has no translation in %s", ErrNotFound, songID, lang)
+		}
+		s.logg.WithError(err).Error("Failed to delete translation")
+		return err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"language": lang,
+	}).Info("Translation deleted successfully")
+	return nil
+}
+
+func (s *LyricsService) SetOriginalLanguage(ctx context.Context, songID int, language string) (entities.SongLyrics, error) {
+	if err := auth.Require(ctx, auth.PermSongsWrite); err != nil {
+		return entities.SongLyrics{}, err
+	}
+	lang, err := normalizeLanguage(language)
+	if err != nil {
+		return entities.SongLyrics{}, err
+	}
+	if _, err := s.songs.GetSong(ctx, songID); err != nil {
+		return entities.SongLyrics{}, err
+	}
+
+	original, err := s.repo.SetOriginalLanguage(ctx, songID, lang)
+	if err != nil {
+		if errors.Is(err, repository.ErrLanguageTaken) {
+			return entities.SongLyrics{}, fmt.Errorf("%w: song %d already has a translation in %s", ErrValidation, songID, lang)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.SongLyrics{}, fmt.Errorf("%w: song %d", ErrNotFound, songID)
+		}
+		s.logg.WithError(err).Error("Failed to set original language")
+		return entities.SongLyrics{}, err
+	}
+	return original, nil
+}
+
+// normalizeLanguage validates a BCP 47 tag and returns its canonical form.
+// "und" is reserved for originals of unknown language.
+func normalizeLanguage(tag string) (string, error) {
+	parsed, err := language.Parse(tag)
+	if err != nil || parsed == language.Und {
+		return "", fmt.Errorf("%w: invalid language tag %q", ErrValidation, tag)
+	}
+	return parsed.String(), nil
+}
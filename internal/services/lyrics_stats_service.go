@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/lyrics"
+	"github.com/senyabanana/library-service/internal/repository"
+
+	"github.com/sirupsen/logrus"
+)
+
+const catalogueTopWords = 20
+
+type LyricsStatsServiceInterface interface {
+	GetSongStats(ctx context.Context, songID int) (entities.LyricsStats, error)
+	GetCatalogueStats(ctx context.Context) (entities.CatalogueLyricsStats, error)
+}
+
+// LyricsStatsCache keeps computed statistics until the song changes. The
+// catalogue statistics also expire after ttl, since songs are also changed by
+// other instances of the service.
+type LyricsStatsCache struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	generation  uint64
+	songs       map[int]entities.LyricsStats
+	catalogue   *entities.CatalogueLyricsStats
+	catalogueAt time.Time
+}
+
+func NewLyricsStatsCache(ttl time.Duration) *LyricsStatsCache {
+	return &LyricsStatsCache{
+		ttl:   ttl,
+		songs: map[int]entities.LyricsStats{},
+	}
+}
+
+// Invalidate drops the statistics of a song and of the catalogue.
+func (c *LyricsStatsCache) Invalidate(songID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.songs, songID)
+	c.catalogue = nil
+}
+
+// InvalidateCatalogue drops the statistics of the catalogue, for changes
+// that add songs without touching cached ones, such as imports.
+func (c *LyricsStatsCache) InvalidateCatalogue() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.catalogue = nil
+}
+
+func (c *LyricsStatsCache) song(songID int) (entities.LyricsStats, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.songs[songID]
+	return stats, c.generation, ok
+}
+
+// setSong stores stats computed at generation, unless an invalidation has
+// happened since.
+func (c *LyricsStatsCache) setSong(stats entities.LyricsStats, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.songs[stats.SongID] = stats
+	}
+}
+
+func (c *LyricsStatsCache) getCatalogue() (entities.CatalogueLyricsStats, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.catalogue == nil || time.Since(c.catalogueAt) > c.ttl {
+		return entities.CatalogueLyricsStats{}, c.generation, false
+	}
+	return *c.catalogue, c.generation, true
+}
+
+func (c *LyricsStatsCache) setCatalogue(stats entities.CatalogueLyricsStats, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.catalogue, c.catalogueAt = &stats, time.Now()
+	}
+}
+
+type LyricsStatsService struct {
+	songs  SongServiceInterface
+	repo   repository.SongRepositoryInterface
+	lyrics repository.LyricsRepositoryInterface
+	cache  *LyricsStatsCache
+	logg   *logger.Logger
+}
+
+func NewLyricsStatsService(songs SongServiceInterface, repo repository.SongRepositoryInterface, lyrics repository.LyricsRepositoryInterface, cache *LyricsStatsCache, logg *logger.Logger) *LyricsStatsService {
+	return &LyricsStatsService{
+		songs:  songs,
+		repo:   repo,
+		lyrics: lyrics,
+		cache:  cache,
+		logg:   logg,
+	}
+}
+
+func (s *LyricsStatsService) GetSongStats(ctx context.Context, songID int) (entities.LyricsStats, error) {
+	s.logg.WithField("song_id", songID).Debug("Fetching lyrics statistics")
+
+	song, err := s.songs.GetSong(ctx, songID)
+	if err != nil {
+		return entities.LyricsStats{}, err
+	}
+	cached, generation, ok := s.cache.song(songID)
+	if ok {
+		return cached, nil
+	}
+
+	original, err := s.lyrics.GetLyrics(ctx, songID, "")
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.logg.WithError(err).Error("Failed to fetch original lyrics from repository")
+		return entities.LyricsStats{}, err
+	}
+
+	stats, _ := lyrics.Analyze(song.Text, original.Language)
+	stats.SongID = songID
+	stats.ComputedAt = time.Now().UTC()
+	s.cache.setSong(stats, generation)
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"words":   stats.Words,
+	}).Info("Lyrics statistics computed successfully")
+	return stats, nil
+}
+
+func (s *LyricsStatsService) GetCatalogueStats(ctx context.Context) (entities.CatalogueLyricsStats, error) {
+	s.logg.Debug("Fetching catalogue lyrics statistics")
+
+	if err := auth.Require(ctx, auth.PermSongsRead); err != nil {
+		return entities.CatalogueLyricsStats{}, err
+	}
+	cached, generation, ok := s.cache.getCatalogue()
+	if ok {
+		return cached, nil
+	}
+
+	languages, err := s.lyrics.GetOriginalLanguages(ctx)
+	if err != nil {
+		return entities.CatalogueLyricsStats{}, err
+	}
+
+	stats := entities.CatalogueLyricsStats{}
+	counts := map[string]int{}
+	var ratios float64
+	query := `SELECT ` + repository.SongColumns + ` FROM songs WHERE deleted_at IS NULL ORDER BY id`
+	err = s.repo.StreamSongsWithQuery(ctx, func(song entities.Song) error {
+		stats.Songs++
+		songStats, songCounts := lyrics.Analyze(song.Text, languages[song.ID])
+		if songStats.Words == 0 {
+			return nil
+		}
+		stats.SongsWithLyrics++
+		stats.Words += songStats.Words
+		stats.Lines += songStats.Lines
+		stats.Verses += songStats.Verses
+		ratios += songStats.UniqueWordRatio
+		for word, count := range songCounts {
+			counts[word] += count
+		}
+		return nil
+	}, query)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to compute catalogue lyrics statistics")
+		return entities.CatalogueLyricsStats{}, err
+	}
+
+	if stats.SongsWithLyrics > 0 {
+		stats.AverageWords = math.Round(float64(stats.Words)/float64(stats.SongsWithLyrics)*10) / 10
+		stats.AverageUniqueWordRatio = math.Round(ratios/float64(stats.SongsWithLyrics)*1000) / 1000
+	}
+	stats.ReadingTimeSeconds = lyrics.ReadingTime(stats.Words)
+	stats.TopWords = lyrics.TopWords(counts, catalogueTopWords)
+	stats.ComputedAt = time.Now().UTC()
+	s.cache.setCatalogue(stats, generation)
+
+	s.logg.WithFields(logrus.Fields{
+		"songs": stats.Songs,
+		"words": stats.Words,
+	}).Info("Catalogue lyrics statistics computed successfully")
+	return stats, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/senyabanana/library-service/internal/auth"
+	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/repository"
+	"github.com/senyabanana/library-service/internal/textdiff"
+
+	"github.com/sirupsen/logrus"
+)
+
+type RevisionServiceInterface interface {
+	GetRevisions(ctx context.Context, songID int) ([]entities.SongRevision, error)
+	GetRevision(ctx context.Context, songID, revision int) (entities.SongRevision, error)
+	DiffRevisions(ctx context.Context, songID, from, to int) (entities.RevisionDiff, error)
+	RestoreRevision(ctx context.Context, songID, revision int) (entities.Song, error)
+}
+
+type RevisionService struct {
+	repo  repository.RevisionRepositoryInterface
+	songs SongServiceInterface
+	logg  *logger.Logger
+}
+
+// NewRevisionService restores revisions through songs, so restores go
+// through the same permission checks, auditing and history as regular
+// updates.
+func NewRevisionService(repo repository.RevisionRepositoryInterface, songs SongServiceInterface, logg *logger.Logger) *RevisionService {
+	return &RevisionService{
+		repo:  repo,
+		songs: songs,
+		logg:  logg,
+	}
+}
+
+func (s *RevisionService) GetRevisions(ctx context.Context, songID int) ([]entities.SongRevision, error) {
+	if err := auth.Require(ctx, auth.PermSongsRead); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repo.GetRevisions(ctx, songID)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch revisions from repository")
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *RevisionService) GetRevision(ctx context.Context, songID, revision int) (entities.SongRevision, error) {
+	if err := auth.Require(ctx, auth.PermSongsRead); err != nil {
+		return entities.SongRevision{}, err
+	}
+
+	rev, err := s.repo.GetRevision(ctx, songID, revision)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.SongRevision{}, fmt.Errorf("%w: revision %d of song %d", ErrNotFound, revision, songID)
+		}
+		s.logg.WithError(err).Error("Failed to fetch revision from repository")
+		return entities.SongRevision{}, err
+	}
+	return rev, nil
+}
+
+// DiffRevisions compares two revisions of a song. When to is zero the latest
+// revision is used, and when from is zero the one preceding to.
+func (s *RevisionService) DiffRevisions(ctx context.Context, songID, from, to int) (entities.RevisionDiff, error) {
+	s.logg.WithFields(logrus.Fields{
+		"song_id": songID,
+		"from":    from,
+		"to":      to,
+	}).Debug("Diffing song revisions")
+
+	if to == 0 {
+		revisions, err := s.GetRevisions(ctx, songID)
+		if err != nil {
+			return entities.RevisionDiff{}, err
+		}
+		if len(revisions) == 0 {
+			return entities.RevisionDiff{}, fmt.Errorf("%w: song %d has no revisions", ErrNotFound, songID)
+		}
+		to = revisions[0].Revision
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 || from == to {
+		return entities.RevisionDiff{}, fmt.Errorf("%w: need two distinct revisions to compare", ErrValidation)
+	}
+
+	a, err := s.GetRevision(ctx, songID, from)
+	if err != nil {
+		return entities.RevisionDiff{}, err
+	}
+	b, err := s.GetRevision(ctx, songID, to)
+	if err != nil {
+		return entities.RevisionDiff{}, err
+	}
+
+	diff := entities.RevisionDiff{SongID: songID, From: from, To: to, Fields: []entities.FieldChange{}}
+	for _, field := range []struct{ name, from, to string }{
+		{"group", a.GroupName, b.GroupName},
+		{"song", a.SongName, b.SongName},
+		{"release_date", a.ReleaseDate, b.ReleaseDate},
+		{"text", a.Text, b.Text},
+		{"link", a.Link, b.Link},
+	} {
+		if field.from != field.to {
+			diff.Fields = append(diff.Fields, entities.FieldChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	if a.Text != b.Text {
+		diff.Text = textdiff.Lines(a.Text, b.Text)
+	}
+	return diff, nil
+}
+
+func (s *RevisionService) RestoreRevision(ctx context.Context, songID, revision int) (entities.Song, error) {
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"revision": revision,
+	}).Debug("Restoring song revision")
+
+	rev, err := s.GetRevision(ctx, songID, revision)
+	if err != nil {
+		return entities.Song{}, err
+	}
+
+	song := entities.Song{
+		ID:          songID,
+		GroupName:   rev.GroupName,
+		SongName:    rev.SongName,
+		ReleaseDate: rev.ReleaseDate,
+		Text:        rev.Text,
+		Link:        rev.Link,
+	}
+	restored, err := s.songs.UpdateSong(ctx, song)
+	if err != nil {
+		return entities.Song{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  songID,
+		"revision": revision,
+	}).Info("Song revision restored successfully")
+	return restored, nil
+}
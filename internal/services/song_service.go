@@ -2,72 +2,211 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
-	//"github.com/senyabanana/library-service/internal/api"
+	"github.com/senyabanana/library-service/internal/enrichment"
 	"github.com/senyabanana/library-service/internal/entities"
+	"github.com/senyabanana/library-service/internal/links"
 	"github.com/senyabanana/library-service/internal/logger"
+	"github.com/senyabanana/library-service/internal/lyrics"
+	"github.com/senyabanana/library-service/internal/profanity"
+	"github.com/senyabanana/library-service/internal/releasedate"
 	"github.com/senyabanana/library-service/internal/repository"
-	
+	"github.com/senyabanana/library-service/internal/songio"
+
 	"github.com/sirupsen/logrus"
 )
 
 type SongServiceInterface interface {
-	AddSong(ctx context.Context, song entities.Song) error
+	AddSong(ctx context.Context, song entities.Song) (entities.Song, error)
+	GetSong(ctx context.Context, id int) (entities.Song, error)
 	GetSongs(ctx context.Context, filters entities.SongFilters, pagination entities.Pagination) ([]entities.Song, error)
-	GetSongText(ctx context.Context, id int, page int, perPage int) ([]string, error)
-	UpdateSong(ctx context.Context, song entities.Song) error
+	ExportSongs(ctx context.Context, filters entities.SongFilters, format string, columns []string, w io.Writer) error
+	GetSongText(ctx context.Context, id int, opts entities.TextOptions) (entities.SongText, error)
+	UpdateSong(ctx context.Context, song entities.Song) (entities.Song, error)
+	ApplyEnrichment(ctx context.Context, id int, details entities.Details, sources []entities.FieldSource) (entities.Song, error)
 	DeleteSong(ctx context.Context, id int) error
+	RestoreSong(ctx context.Context, id int) error
+	HardDeleteSong(ctx context.Context, id int) error
+	GetTrash(ctx context.Context, pagination entities.Pagination) ([]entities.Song, error)
+	GetDuplicateReports(ctx context.Context) ([]entities.DuplicateReport, error)
+	PreviewMerge(ctx context.Context, req entities.MergeRequest) (entities.MergePreview, error)
+	MergeSongs(ctx context.Context, req entities.MergeRequest) (entities.MergePreview, error)
+	ResolveSongID(ctx context.Context, id int) (int, bool, error)
+	ExecuteBatch(ctx context.Context, req entities.BatchRequest) (entities.BatchResponse, error)
+	SetExplicit(ctx context.Context, id int, explicit *bool) (entities.Song, error)
 }
 
+const maxBatchOperations = 1000
+
+// errBatchFailed rolls back an atomic batch once one of its operations fails.
+var errBatchFailed = errors.New("batch operation failed")
+
 type SongService struct {
-	repo repository.SongRepositoryInterface
-	logg *logger.Logger
+	repo      repository.SongRepositoryInterface
+	revisions repository.RevisionRepositoryInterface
+	lyrics    repository.LyricsRepositoryInterface
+	uow       repository.UnitOfWork
+	stats     *LyricsStatsCache
+	profanity *profanity.Dictionary
+	enricher  *enrichment.Pipeline
+	logg      *logger.Logger
 }
 
-func NewSongService(repo repository.SongRepositoryInterface, logg *logger.Logger) *SongService {
+func NewSongService(repo repository.SongRepositoryInterface, revisions repository.RevisionRepositoryInterface, lyrics repository.LyricsRepositoryInterface, uow repository.UnitOfWork, stats *LyricsStatsCache, dictionary *profanity.Dictionary, enricher *enrichment.Pipeline, logg *logger.Logger) *SongService {
 	return &SongService{
-		repo: repo,
-		logg: logg,
+		repo:      repo,
+		revisions: revisions,
+		lyrics:    lyrics,
+		uow:       uow,
+		stats:     stats,
+		profanity: dictionary,
+		enricher:  enricher,
+		logg:      logg,
 	}
 }
 
-func (s *SongService) AddSong(ctx context.Context, song entities.Song) error {
+func (s *SongService) AddSong(ctx context.Context, song entities.Song) (entities.Song, error) {
 	s.logg.WithFields(logrus.Fields{
 		"group": song.GroupName,
 		"song":  song.SongName,
 	}).Debug("Adding new song")
 
+	song, sources, err := s.prepareSong(ctx, song)
+	if err != nil {
+		return entities.Song{}, err
+	}
+	return s.addSong(ctx, song, sources)
+}
+
+// prepareSong validates a new song and fills it from the enrichment
+// providers. It makes no changes, so it can run ahead of a transaction.
+func (s *SongService) prepareSong(ctx context.Context, song entities.Song) (entities.Song, []entities.FieldSource, error) {
 	if song.GroupName == "" || song.SongName == "" {
-		err := errors.New("group name and song name are required")
+		err := fmt.Errorf("%w: group name and song name are required", ErrValidation)
 		s.logg.WithError(err).Error("Validation failed")
-		return err
+		return entities.Song{}, nil, err
 	}
 
-	//apiClient := api.NewMusicAPIClient("http://external-api")
-	//details, err := apiClient.FetchSongDetails(song.GroupName, song.SongName)
-	//if err != nil {
-	//	return err
-	//}
-
-	// Заглушка вместо реального API-запроса
-	song.ReleaseDate = "2024-01-01"
-	song.Text = "Sample lyrics for " + song.SongName
-	song.Link = "https://example.com/" + song.SongName
+	sources, err := s.enrich(ctx, &song)
+	if err != nil {
+		return entities.Song{}, nil, err
+	}
+	song.Text = lyrics.NormalizeText(song.Text)
+	song.Explicit = s.profanity.Explicit(song.Text, "")
+	if err := normalizeReleaseDate(&song); err != nil {
+		s.logg.WithError(err).Error("Validation failed")
+		return entities.Song{}, nil, err
+	}
+	if err := normalizeLink(&song); err != nil {
+		s.logg.WithError(err).Error("Validation failed")
+		return entities.Song{}, nil, err
+	}
+	return song, sources, nil
+}
 
-	err := s.repo.AddSong(ctx, song)
+// addSong stores a song prepared by prepareSong.
+func (s *SongService) addSong(ctx context.Context, song entities.Song, sources []entities.FieldSource) (entities.Song, error) {
+	var created entities.Song
+	err := s.uow.WithTx(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		var err error
+		if created, err = repos.Songs.AddSong(ctx, song); err != nil {
+			return err
+		}
+		if err := repos.Enrichment.SaveFieldSources(ctx, created.ID, sources); err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(sources, func(source entities.FieldSource) bool { return source.Provider != entities.SourceManual }) {
+			return nil
+		}
+		now := time.Now()
+		created.EnrichedAt = &now
+		return repos.Enrichment.MarkEnriched(ctx, created.ID, now)
+	})
 	if err != nil {
+		if errors.Is(err, repository.ErrDuplicateSong) {
+			return entities.Song{}, s.duplicateError(ctx, song.GroupName, song.SongName, 0)
+		}
 		s.logg.WithError(err).Error("Failed to add song to repository")
-		return err
+		return entities.Song{}, err
 	}
 
+	s.stats.Invalidate(created.ID)
+
 	s.logg.WithFields(logrus.Fields{
-		"group": song.GroupName,
-		"song":  song.SongName,
+		"song_id": created.ID,
+		"group":   created.GroupName,
+		"song":    created.SongName,
 	}).Info("Song added successfully")
-	return nil
+	return created, nil
+}
+
+// enrich fills the release date, text and link of a new song that were not
+// given from the enrichment providers and returns where each came from;
+// fields that were given are manual.
+func (s *SongService) enrich(ctx context.Context, song *entities.Song) ([]entities.FieldSource, error) {
+	given := map[string]string{
+		enrichment.FieldReleaseDate: song.ReleaseDate,
+		enrichment.FieldText:        song.Text,
+		enrichment.FieldLink:        song.Link,
+	}
+	var missing []string
+	var sources []entities.FieldSource
+	for _, field := range enrichment.Fields {
+		if strings.TrimSpace(given[field]) == "" {
+			missing = append(missing, field)
+		} else {
+			sources = append(sources, entities.FieldSource{Field: field, Provider: entities.SourceManual, EnrichedAt: time.Now()})
+		}
+	}
+	if len(missing) == 0 {
+		return sources, nil
+	}
+
+	result, err := s.enricher.Enrich(ctx, song.GroupName, song.SongName, missing...)
+	if err != nil {
+		if errors.Is(err, enrichment.ErrNotFound) {
+			return nil, fmt.Errorf("%w: no enrichment provider knows %q by %q", ErrValidation, song.SongName, song.GroupName)
+		}
+		s.logg.WithError(err).Error("Failed to enrich song")
+		return nil, err
+	}
+	for _, source := range result.Sources {
+		switch source.Field {
+		case enrichment.FieldReleaseDate:
+			song.ReleaseDate = result.Details.ReleaseDate
+		case enrichment.FieldText:
+			song.Text = result.Details.Text
+		case enrichment.FieldLink:
+			song.Link = result.Details.Link
+		}
+	}
+	return append(sources, result.Sources...), nil
+}
+
+// GetSong returns a live song; trashed songs are reported as not found.
+func (s *SongService) GetSong(ctx context.Context, id int) (entities.Song, error) {
+	s.logg.WithField("song_id", id).Debug("Fetching song")
+
+	song, err := s.repo.GetSongByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.Song{}, fmt.Errorf("%w: song %d", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to fetch song from repository")
+		return entities.Song{}, err
+	}
+	if song.DeletedAt != nil {
+		return entities.Song{}, fmt.Errorf("%w: song %d", ErrNotFound, id)
+	}
+	return song, nil
 }
 
 func (s *SongService) GetSongs(ctx context.Context, filters entities.SongFilters, pagination entities.Pagination) ([]entities.Song, error) {
@@ -76,23 +215,11 @@ func (s *SongService) GetSongs(ctx context.Context, filters entities.SongFilters
 		"pagination": pagination,
 	}).Debug("Fetching songs with filters")
 
-	query := `SELECT id, group_name, song_name, release_date, text, link FROM songs WHERE 1=1`
-	args := []interface{}{}
-	argIndex := 1
-
-	if filters.GroupName != "" {
-		query += ` AND group_name ILIKE $` + strconv.Itoa(argIndex)
-		args = append(args, "%"+filters.GroupName+"%")
-		argIndex++
-	}
-	if filters.SongName != "" {
-		query += ` AND song_name ILIKE $` + strconv.Itoa(argIndex)
-		args = append(args, "%"+filters.SongName+"%")
-		argIndex++
-	}
+	query, args := songFilterQuery(filters)
+	argIndex := len(args) + 1
 
 	offset := (pagination.Page - 1) * pagination.PerPage
-	query += ` LIMIT $` + strconv.Itoa(argIndex) + ` OFFSET $` + strconv.Itoa(argIndex+1)
+	query += ` ORDER BY id LIMIT $` + strconv.Itoa(argIndex) + ` OFFSET $` + strconv.Itoa(argIndex+1)
 	args = append(args, pagination.PerPage, offset)
 
 	songs, err := s.repo.GetSongsWithQuery(ctx, query, args...)
@@ -105,90 +232,716 @@ func (s *SongService) GetSongs(ctx context.Context, filters entities.SongFilters
 	return songs, nil
 }
 
-func (s *SongService) GetSongText(ctx context.Context, id int, page int, perPage int) ([]string, error) {
+// ExportSongs streams every live song matching filters to w in the given
+// format, reading them through a database cursor.
+func (s *SongService) ExportSongs(ctx context.Context, filters entities.SongFilters, format string, columns []string, w io.Writer) error {
 	s.logg.WithFields(logrus.Fields{
-		"song_id": id,
-		"page":    page,
-		"perPage": perPage,
-	}).Debug("Fetching song text")
+		"filters": filters,
+		"format":  format,
+		"columns": columns,
+	}).Debug("Exporting songs")
 
-	songs, err := s.repo.GetSongs(ctx)
+	writer, err := songio.NewWriter(w, format, columns)
 	if err != nil {
-		s.logg.WithError(err).Error("Failed to fetch songs from repository")
-		return nil, err
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	query, args := songFilterQuery(filters)
+	query += ` ORDER BY id`
+
+	count := 0
+	err = s.repo.StreamSongsWithQuery(ctx, func(song entities.Song) error {
+		count++
+		return writer.Write(song)
+	}, query, args...)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to export songs")
+		return err
+	}
+
+	s.logg.WithField("count", count).Info("Songs exported successfully")
+	return nil
+}
+
+// songFilterQuery builds the SELECT over live songs shared by listing and
+// export, without ordering or pagination.
+func songFilterQuery(filters entities.SongFilters) (string, []interface{}) {
+	query := `SELECT ` + repository.SongColumns + ` FROM songs WHERE deleted_at IS NULL`
+	args := []interface{}{}
+
+	if filters.GroupName != "" {
+		args = append(args, "%"+filters.GroupName+"%")
+		query += ` AND group_name ILIKE $` + strconv.Itoa(len(args))
+	}
+	if filters.SongName != "" {
+		args = append(args, "%"+filters.SongName+"%")
+		query += ` AND song_name ILIKE $` + strconv.Itoa(len(args))
 	}
+	if filters.Explicit != nil {
+		args = append(args, *filters.Explicit)
+		query += ` AND explicit = $` + strconv.Itoa(len(args))
+	}
+	return query, args
+}
+
+// GetSongText returns a page of sections of the lyrics in opts.Language, or
+// of the original when no language is given. In bilingual mode each section
+// of the original is paired with the section at the same position in the
+// translation.
+func (s *SongService) GetSongText(ctx context.Context, id int, opts entities.TextOptions) (entities.SongText, error) {
+	s.logg.WithFields(logrus.Fields{
+		"song_id": id,
+		"options": opts,
+	}).Debug("Fetching song text")
 
-	var song entities.Song
-	for _, s := range songs {
-		if s.ID == id {
-			song = s
-			break
+	if opts.Bilingual && opts.Language == "" {
+		return entities.SongText{}, fmt.Errorf("%w: lang is required for bilingual text", ErrValidation)
+	}
+	if opts.Language != "" {
+		lang, err := normalizeLanguage(opts.Language)
+		if err != nil {
+			return entities.SongText{}, err
+		}
+		opts.Language = lang
+	}
+	for _, typ := range opts.Types {
+		if !lyrics.IsSectionType(typ) {
+			return entities.SongText{}, fmt.Errorf("%w: unknown section type %q", ErrValidation, typ)
 		}
 	}
 
-	if song.ID == 0 {
-		err := errors.New("song not found")
-		s.logg.WithField("song_id", id).Error(err.Error())
-		return nil, err
+	if _, err := s.GetSong(ctx, id); err != nil {
+		return entities.SongText{}, err
 	}
 
-	verses := strings.Split(song.Text, "\n\n")
-	start := (page - 1) * perPage
-	if start >= len(verses) {
-		s.logg.WithFields(logrus.Fields{
-			"song_id": id,
-			"page":    page,
-		}).Debug("No verses found for requested page")
-		return nil, nil
+	original, err := s.lyrics.GetLyrics(ctx, id, "")
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch original lyrics from repository")
+		return entities.SongText{}, err
 	}
-	end := start + perPage
-	if end > len(verses) {
-		end = len(verses)
+	version := original
+	if opts.Language != "" && opts.Language != original.Language {
+		version, err = s.lyrics.GetLyrics(ctx, id, opts.Language)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entities.SongText{}, fmt.Errorf("%w: song %d has no lyrics in %s", ErrNotFound, id, opts.Language)
+			}
+			s.logg.WithError(err).Error("Failed to fetch lyrics from repository")
+			return entities.SongText{}, err
+		}
 	}
+
+	text := entities.SongText{SongID: id, Language: version.Language}
+	sections := s.sections(ctx, version)
+	if opts.Bilingual {
+		text.OriginalLanguage = original.Language
+		translated := sections
+		sections = s.sections(ctx, original)
+		for i := range sections {
+			if i < len(translated) {
+				sections[i].Translation = translated[i].Lines
+			}
+		}
+		for _, extra := range translated[min(len(sections), len(translated)):] {
+			extra.Number = len(sections) + 1
+			extra.Translation, extra.Lines, extra.RepeatOf = extra.Lines, []string{}, 0
+			sections = append(sections, extra)
+		}
+	}
+	if opts.ExpandRepeats {
+		sections = lyrics.ExpandRepeats(sections)
+	}
+	if opts.Mask {
+		lines, translation := version.Language, ""
+		if opts.Bilingual {
+			lines, translation = original.Language, version.Language
+		}
+		for i := range sections {
+			sections[i].Lines = s.maskLines(sections[i].Lines, lines)
+			sections[i].Translation = s.maskLines(sections[i].Translation, translation)
+		}
+	}
+	if len(opts.Types) > 0 {
+		filtered := []entities.LyricSection{}
+		for _, section := range sections {
+			if slices.Contains(opts.Types, section.Type) {
+				filtered = append(filtered, section)
+			}
+		}
+		sections = filtered
+	}
+
+	text.Total = len(sections)
+	start := min((opts.Page-1)*opts.PerPage, len(sections))
+	end := min(start+opts.PerPage, len(sections))
+	text.Sections = append([]entities.LyricSection{}, sections[start:end]...)
+
 	s.logg.WithFields(logrus.Fields{
 		"song_id": id,
-		"page":    page,
+		"page":    opts.Page,
 	}).Info("Fetched song text successfully")
-	return verses[start:end], nil
+	return text, nil
+}
+
+func (s *SongService) maskLines(lines []string, lang string) []string {
+	if lines == nil {
+		return nil
+	}
+	masked := make([]string, len(lines))
+	for i, line := range lines {
+		masked[i] = s.profanity.Mask(line, lang)
+	}
+	return masked
+}
+
+// sections returns the parsed text of a version, parsing and storing it if
+// that has not been done since the text last changed.
+func (s *SongService) sections(ctx context.Context, version entities.SongLyrics) []entities.LyricSection {
+	if version.Sections != nil {
+		return version.Sections
+	}
+	version.Sections = lyrics.ParseSections(version.Text)
+	if err := s.lyrics.SaveSections(ctx, version); err != nil {
+		s.logg.WithError(err).WithField("song_id", version.SongID).Warn("Failed to store parsed lyrics sections")
+	}
+	return version.Sections
 }
 
-func (s *SongService) UpdateSong(ctx context.Context, song entities.Song) error {
+// SetExplicit flags a song as explicit or not by hand. A nil explicit hands
+// the flag back to detection from the lyrics.
+func (s *SongService) SetExplicit(ctx context.Context, id int, explicit *bool) (entities.Song, error) {
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  id,
+		"explicit": explicit,
+	}).Debug("Setting explicit flag")
+
+	manual := explicit != nil
+	var value bool
+	if manual {
+		value = *explicit
+	} else {
+		song, err := s.GetSong(ctx, id)
+		if err != nil {
+			return entities.Song{}, err
+		}
+		value = s.profanity.Explicit(song.Text, s.originalLanguage(ctx, id))
+	}
+
+	updated, err := s.repo.SetExplicit(ctx, id, value, manual)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.Song{}, fmt.Errorf("%w: song %d", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to set explicit flag in repository")
+		return entities.Song{}, err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"song_id":  id,
+		"explicit": updated.Explicit,
+		"manual":   manual,
+	}).Info("Explicit flag set successfully")
+	return updated, nil
+}
+
+// manualSources returns a manual source for every enrichable field that
+// differs between before and after.
+func manualSources(before, after entities.Song) []entities.FieldSource {
+	var sources []entities.FieldSource
+	add := func(field string, changed bool) {
+		if changed {
+			sources = append(sources, entities.FieldSource{Field: field, Provider: entities.SourceManual, EnrichedAt: time.Now()})
+		}
+	}
+	add(enrichment.FieldReleaseDate, before.ReleaseDate != after.ReleaseDate)
+	add(enrichment.FieldText, before.Text != after.Text)
+	add(enrichment.FieldLink, before.Link != after.Link)
+	return sources
+}
+
+// normalizeReleaseDate rewrites the release date of song in ISO 8601 to the
+// precision it was given in.
+func normalizeReleaseDate(song *entities.Song) error {
+	released, err := releasedate.Parse(song.ReleaseDate)
+	if err != nil {
+		return fmt.Errorf("%w: release_date: %v", ErrValidation, err)
+	}
+	song.ReleaseDate, song.ReleaseDatePrecision = released.String(), released.Precision
+	return nil
+}
+
+// normalizeLink rewrites the link of song in canonical form. A song may have
+// no link at all.
+func normalizeLink(song *entities.Song) error {
+	if song.Link == "" {
+		return nil
+	}
+	link, err := links.Canonicalize(song.Link)
+	if err != nil {
+		return fmt.Errorf("%w: link %q is not an absolute http or https URL", ErrValidation, song.Link)
+	}
+	song.Link = link
+	return nil
+}
+
+// originalLanguage returns the language of the song's original lyrics, or ""
+// when it is unknown.
+func (s *SongService) originalLanguage(ctx context.Context, id int) string {
+	original, err := s.lyrics.GetLyrics(ctx, id, "")
+	if err != nil {
+		return ""
+	}
+	return original.Language
+}
+
+// UpdateSong saves an edit made by hand. The release date, text and link it
+// changes are recorded as manual, so re-enrichment does not overwrite them.
+func (s *SongService) UpdateSong(ctx context.Context, song entities.Song) (entities.Song, error) {
+	return s.updateSong(ctx, song, nil)
+}
+
+// ApplyEnrichment sets the fields named in sources to the values in
+// details and records where they came from.
+func (s *SongService) ApplyEnrichment(ctx context.Context, id int, details entities.Details, sources []entities.FieldSource) (entities.Song, error) {
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		return entities.Song{}, err
+	}
+	for _, source := range sources {
+		switch source.Field {
+		case enrichment.FieldReleaseDate:
+			song.ReleaseDate = details.ReleaseDate
+		case enrichment.FieldText:
+			song.Text = details.Text
+		case enrichment.FieldLink:
+			song.Link = details.Link
+		default:
+			return entities.Song{}, fmt.Errorf("%w: field %q cannot be enriched", ErrValidation, source.Field)
+		}
+	}
+	return s.updateSong(ctx, song, sources)
+}
+
+// updateSong saves song. Without sources, the enrichable fields the update
+// changes are recorded as edited by hand; with sources, those are recorded
+// and the song is marked as enriched.
+func (s *SongService) updateSong(ctx context.Context, song entities.Song, sources []entities.FieldSource) (entities.Song, error) {
 	s.logg.WithFields(logrus.Fields{
 		"song_id": song.ID,
 		"song":    song.SongName,
 	}).Debug("Updating song")
 
 	if song.ID == 0 {
-		err := errors.New("song ID is required for update")
+		err := fmt.Errorf("%w: song ID is required for update", ErrValidation)
 		s.logg.WithError(err).Error("Validation failed")
-		return err
+		return entities.Song{}, err
+	}
+	if err := normalizeReleaseDate(&song); err != nil {
+		s.logg.WithError(err).Error("Validation failed")
+		return entities.Song{}, err
+	}
+	if err := normalizeLink(&song); err != nil {
+		s.logg.WithError(err).Error("Validation failed")
+		return entities.Song{}, err
 	}
+	song.Text = lyrics.NormalizeText(song.Text)
+	song.Explicit = s.profanity.Explicit(song.Text, s.originalLanguage(ctx, song.ID))
 
-	err := s.repo.UpdateSong(ctx, song)
+	var updated entities.Song
+	err := s.uow.WithTx(ctx, func(ctx context.Context, repos repository.Repositories) error {
+		before, err := repos.Songs.GetSongByID(ctx, song.ID)
+		if err != nil {
+			return err
+		}
+		if err := repos.Revisions.EnsureBaselineRevision(ctx, song.ID); err != nil {
+			return err
+		}
+		if updated, err = repos.Songs.UpdateSong(ctx, song); err != nil {
+			return err
+		}
+		if _, err = repos.Revisions.AddRevision(ctx, song.ID); err != nil {
+			return err
+		}
+
+		if sources == nil {
+			return repos.Enrichment.SaveFieldSources(ctx, song.ID, manualSources(before, updated))
+		}
+		if err := repos.Enrichment.SaveFieldSources(ctx, song.ID, sources); err != nil {
+			return err
+		}
+		now := time.Now()
+		updated.EnrichedAt = &now
+		return repos.Enrichment.MarkEnriched(ctx, song.ID, now)
+	})
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.Song{}, fmt.Errorf("%w: song %d", ErrNotFound, song.ID)
+		}
+		if errors.Is(err, repository.ErrDuplicateSong) {
+			return entities.Song{}, s.duplicateError(ctx, song.GroupName, song.SongName, song.ID)
+		}
 		s.logg.WithError(err).Error("Failed to update song in repository")
-		return err
+		return entities.Song{}, err
 	}
 
+	s.stats.Invalidate(song.ID)
+
 	s.logg.WithField("song_id", song.ID).Info("Song updated successfully")
-	return nil
+	return updated, nil
 }
 
 func (s *SongService) DeleteSong(ctx context.Context, id int) error {
 	s.logg.WithField("song_id", id).Debug("Deleting song")
 
 	if id == 0 {
-		err := errors.New("song ID is required for delete")
+		err := fmt.Errorf("%w: song ID is required for delete", ErrValidation)
 		s.logg.WithError(err).Error("Validation failed")
 		return err
 	}
 
 	err := s.repo.DeleteSong(ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: song %d", ErrNotFound, id)
+		}
 		s.logg.WithError(err).Error("Failed to delete song from repository")
 		return err
 	}
 
+	s.stats.Invalidate(id)
+
 	s.logg.WithField("song_id", id).Info("Song deleted successfully")
 	return nil
 }
+
+func (s *SongService) RestoreSong(ctx context.Context, id int) error {
+	s.logg.WithField("song_id", id).Debug("Restoring song from trash")
+
+	err := s.repo.RestoreSong(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: song %d is not in the trash", ErrNotFound, id)
+		}
+		if errors.Is(err, repository.ErrDuplicateSong) {
+			song, getErr := s.repo.GetSongByID(ctx, id)
+			if getErr != nil {
+				return getErr
+			}
+			return s.duplicateError(ctx, song.GroupName, song.SongName, id)
+		}
+		s.logg.WithError(err).Error("Failed to restore song in repository")
+		return err
+	}
+
+	s.stats.Invalidate(id)
+
+	s.logg.WithField("song_id", id).Info("Song restored successfully")
+	return nil
+}
+
+func (s *SongService) HardDeleteSong(ctx context.Context, id int) error {
+	s.logg.WithField("song_id", id).Debug("Permanently deleting song")
+
+	err := s.repo.HardDeleteSong(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: song %d", ErrNotFound, id)
+		}
+		s.logg.WithError(err).Error("Failed to permanently delete song from repository")
+		return err
+	}
+
+	s.stats.Invalidate(id)
+
+	s.logg.WithField("song_id", id).Info("Song permanently deleted successfully")
+	return nil
+}
+
+func (s *SongService) GetTrash(ctx context.Context, pagination entities.Pagination) ([]entities.Song, error) {
+	s.logg.WithField("pagination", pagination).Debug("Fetching trashed songs")
+
+	query := `SELECT ` + repository.SongColumns + ` FROM songs WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT $1 OFFSET $2`
+	songs, err := s.repo.GetSongsWithQuery(ctx, query, pagination.PerPage, (pagination.Page-1)*pagination.PerPage)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch trashed songs from repository")
+		return nil, err
+	}
+
+	s.logg.WithField("count", len(songs)).Info("Trashed songs fetched successfully")
+	return songs, nil
+}
+
+func (s *SongService) GetDuplicateReports(ctx context.Context) ([]entities.DuplicateReport, error) {
+	reports, err := s.repo.GetDuplicateReports(ctx)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to fetch duplicate reports from repository")
+		return nil, err
+	}
+	return reports, nil
+}
+
+var mergeFields = []string{"group", "song", "release_date", "text", "link"}
+
+// PreviewMerge computes the outcome of a merge without changing anything.
+// Every field is taken from the survivor unless req.Fields names another of
+// the merged songs as its source.
+func (s *SongService) PreviewMerge(ctx context.Context, req entities.MergeRequest) (entities.MergePreview, error) {
+	s.logg.WithField("request", req).Debug("Previewing song merge")
+
+	if req.SurvivorID <= 0 || len(req.SongIDs) == 0 {
+		return entities.MergePreview{}, fmt.Errorf("%w: survivor_id and at least one song in song_ids are required", ErrValidation)
+	}
+
+	songs := map[int]entities.Song{}
+	for _, id := range append([]int{req.SurvivorID}, req.SongIDs...) {
+		if _, seen := songs[id]; seen {
+			return entities.MergePreview{}, fmt.Errorf("%w: song %d is listed more than once", ErrValidation, id)
+		}
+		song, err := s.repo.GetSongByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entities.MergePreview{}, fmt.Errorf("%w: song %d", ErrNotFound, id)
+			}
+			return entities.MergePreview{}, err
+		}
+		if song.DeletedAt != nil {
+			return entities.MergePreview{}, fmt.Errorf("%w: song %d is in the trash", ErrValidation, id)
+		}
+		songs[id] = song
+	}
+
+	preview := entities.MergePreview{
+		Survivor:     songs[req.SurvivorID],
+		MergedIDs:    req.SongIDs,
+		FieldSources: map[string]int{},
+	}
+	for _, field := range mergeFields {
+		preview.FieldSources[field] = req.SurvivorID
+	}
+	for field, sourceID := range req.Fields {
+		source, ok := songs[sourceID]
+		if !ok {
+			return entities.MergePreview{}, fmt.Errorf("%w: source %d for %s is not one of the merged songs", ErrValidation, sourceID, field)
+		}
+		switch field {
+		case "group":
+			preview.Survivor.GroupName = source.GroupName
+		case "song":
+			preview.Survivor.SongName = source.SongName
+		case "release_date":
+			preview.Survivor.ReleaseDate = source.ReleaseDate
+		case "text":
+			preview.Survivor.Text = source.Text
+		case "link":
+			preview.Survivor.Link = source.Link
+		default:
+			return entities.MergePreview{}, fmt.Errorf("%w: unknown field %q, expected one of %s", ErrValidation, field, strings.Join(mergeFields, ", "))
+		}
+		preview.FieldSources[field] = sourceID
+	}
+	if !preview.Survivor.ExplicitManual {
+		preview.Survivor.Explicit = s.profanity.Explicit(preview.Survivor.Text, s.originalLanguage(ctx, req.SurvivorID))
+	}
+
+	for _, id := range req.SongIDs {
+		revisions, err := s.revisions.GetRevisions(ctx, id)
+		if err != nil {
+			return entities.MergePreview{}, err
+		}
+		preview.ReassignedRevisions += len(revisions)
+	}
+
+	return preview, nil
+}
+
+func (s *SongService) MergeSongs(ctx context.Context, req entities.MergeRequest) (entities.MergePreview, error) {
+	preview, err := s.PreviewMerge(ctx, req)
+	if err != nil {
+		return entities.MergePreview{}, err
+	}
+
+	preview.MergeMoves, err = s.repo.MergeSongs(ctx, preview.Survivor, preview.MergedIDs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.MergePreview{}, fmt.Errorf("%w: one of the merged songs no longer exists", ErrNotFound)
+		}
+		if errors.Is(err, repository.ErrDuplicateSong) {
+			return entities.MergePreview{}, s.duplicateError(ctx, preview.Survivor.GroupName, preview.Survivor.SongName, preview.Survivor.ID)
+		}
+		s.logg.WithError(err).Error("Failed to merge songs in repository")
+		return entities.MergePreview{}, err
+	}
+
+	s.stats.Invalidate(preview.Survivor.ID)
+
+	s.logg.WithFields(logrus.Fields{
+		"survivor_id": preview.Survivor.ID,
+		"merged_ids":  preview.MergedIDs,
+		"moved":       preview.MergeMoves,
+	}).Info("Songs merged successfully")
+	return preview, nil
+}
+
+// ResolveSongID reports the song that a merged-away ID now points to.
+func (s *SongService) ResolveSongID(ctx context.Context, id int) (int, bool, error) {
+	target, err := s.repo.GetRedirect(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return target, true, nil
+}
+
+// ExecuteBatch applies a list of create, update and delete operations. By
+// default they run in one transaction that is committed only if all of them
+// succeed; operations after the first failure are skipped. New songs are
+// validated and enriched before the transaction is opened, so that it is not
+// held across calls to enrichment providers, and a batch with an invalid
+// operation is not started at all. In best-effort mode each operation is
+// applied on its own.
+func (s *SongService) ExecuteBatch(ctx context.Context, req entities.BatchRequest) (entities.BatchResponse, error) {
+	s.logg.WithFields(logrus.Fields{
+		"operations":  len(req.Operations),
+		"best_effort": req.BestEffort,
+	}).Debug("Executing batch")
+
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOperations {
+		err := fmt.Errorf("%w: a batch must contain between 1 and %d operations", ErrValidation, maxBatchOperations)
+		s.logg.WithError(err).Error("Validation failed")
+		return entities.BatchResponse{}, err
+	}
+
+	resp := entities.BatchResponse{
+		BestEffort: req.BestEffort,
+		Results:    make([]entities.BatchResult, len(req.Operations)),
+	}
+
+	if req.BestEffort {
+		for i, op := range req.Operations {
+			prepared, err := s.prepareBatchOperation(ctx, op)
+			if err != nil {
+				resp.Results[i] = entities.BatchResult{Index: i, Op: op.Op, ID: op.ID, Err: err}
+				continue
+			}
+			resp.Results[i] = s.applyBatchOperation(ctx, i, op, prepared)
+		}
+		resp.Committed = true
+		return resp, nil
+	}
+
+	prepared := make([]*preparedSong, len(req.Operations))
+	for i, op := range req.Operations {
+		var err error
+		if prepared[i], err = s.prepareBatchOperation(ctx, op); err != nil {
+			for j, op := range req.Operations {
+				resp.Results[j] = entities.BatchResult{Index: j, Op: op.Op, ID: op.ID, Skipped: true}
+			}
+			resp.Results[i] = entities.BatchResult{Index: i, Op: op.Op, ID: op.ID, Err: err}
+			s.logg.WithError(err).WithField("index", i).Warn("Batch rejected before execution")
+			return resp, nil
+		}
+	}
+
+	err := s.uow.WithTx(ctx, func(ctx context.Context, _ repository.Repositories) error {
+		failed := false
+		for i, op := range req.Operations {
+			if failed {
+				resp.Results[i] = entities.BatchResult{Index: i, Op: op.Op, ID: op.ID, Skipped: true}
+				continue
+			}
+			resp.Results[i] = s.applyBatchOperation(ctx, i, op, prepared[i])
+			failed = resp.Results[i].Err != nil
+		}
+		if failed {
+			return errBatchFailed
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchFailed) {
+		// Songs created before the failure were rolled back with it.
+		for i := range resp.Results {
+			if resp.Results[i].Op == entities.BatchOpCreate {
+				resp.Results[i].ID = 0
+			}
+		}
+		s.logg.Warn("Batch rolled back after a failed operation")
+		return resp, nil
+	}
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to execute batch transaction")
+		return entities.BatchResponse{}, err
+	}
+	resp.Committed = true
+
+	s.logg.WithField("operations", len(req.Operations)).Info("Batch executed successfully")
+	return resp, nil
+}
+
+// preparedSong is a new song of a batch, validated and enriched ahead of
+// the batch.
+type preparedSong struct {
+	song    entities.Song
+	sources []entities.FieldSource
+}
+
+// prepareBatchOperation checks an operation and prepares the song of a
+// create. It does not write anything.
+func (s *SongService) prepareBatchOperation(ctx context.Context, op entities.BatchOperation) (*preparedSong, error) {
+	switch op.Op {
+	case entities.BatchOpCreate:
+		if op.Song == nil {
+			return nil, fmt.Errorf("%w: song is required for create", ErrValidation)
+		}
+		song, sources, err := s.prepareSong(ctx, *op.Song)
+		if err != nil {
+			return nil, err
+		}
+		return &preparedSong{song: song, sources: sources}, nil
+	case entities.BatchOpUpdate:
+		if op.Song == nil {
+			return nil, fmt.Errorf("%w: song is required for update", ErrValidation)
+		}
+	case entities.BatchOpDelete:
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrValidation, op.Op)
+	}
+	return nil, nil
+}
+
+func (s *SongService) applyBatchOperation(ctx context.Context, index int, op entities.BatchOperation, prepared *preparedSong) entities.BatchResult {
+	result := entities.BatchResult{Index: index, Op: op.Op, ID: op.ID}
+
+	switch op.Op {
+	case entities.BatchOpCreate:
+		var created entities.Song
+		created, result.Err = s.addSong(ctx, prepared.song, prepared.sources)
+		result.ID = created.ID
+	case entities.BatchOpUpdate:
+		song := *op.Song
+		song.ID = op.ID
+		_, result.Err = s.UpdateSong(ctx, song)
+	case entities.BatchOpDelete:
+		result.Err = s.DeleteSong(ctx, op.ID)
+	}
+	return result
+}
+
+func (s *SongService) duplicateError(ctx context.Context, group, song string, excludeID int) error {
+	existingID, err := s.repo.FindDuplicateSong(ctx, group, song, excludeID)
+	if err != nil {
+		s.logg.WithError(err).Error("Failed to look up conflicting song")
+		return err
+	}
+
+	s.logg.WithFields(logrus.Fields{
+		"group":       group,
+		"song":        song,
+		"existing_id": existingID,
+	}).Warn("Rejected duplicate song")
+	return &DuplicateError{ExistingID: existingID}
+}
@@ -0,0 +1,154 @@
+package songio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// maxLineSize bounds a single NDJSON record, which may hold full lyrics.
+const maxLineSize = 4 << 20
+
+var csvColumns = map[string]string{
+	"id":              "id",
+	"group":           "group",
+	"group_name":      "group",
+	"song":            "song",
+	"song_name":       "song",
+	"release_date":    "release_date",
+	"text":            "text",
+	"link":            "link",
+	"allow_duplicate": "allow_duplicate",
+	"explicit":        "explicit",
+}
+
+// RowFunc receives each record read from an import file. row is the line the
+// record starts on; err is set when that record could not be decoded, in
+// which case reading continues with the next record.
+type RowFunc func(row int, song entities.Song, err error) error
+
+// Read streams songs from r in the given format, calling fn once per record.
+// It returns early only when the input cannot be read at all or fn fails.
+func Read(r io.Reader, format string, fn RowFunc) error {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, fn)
+	case FormatNDJSON:
+		return readNDJSON(r, fn)
+	default:
+		return fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+func readCSV(r io.Reader, fn RowFunc) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return errors.New("CSV file is empty")
+		}
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	found := map[string]bool{}
+	for i, name := range header {
+		column, ok := csvColumns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))]
+		if !ok {
+			return fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[i] = column
+		found[column] = true
+	}
+	if !found["group"] || !found["song"] {
+		return errors.New("CSV header must include group and song columns")
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if err := fn(parseErr.StartLine, entities.Song{}, parseErr.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		line, _ := reader.FieldPos(0)
+		var song entities.Song
+		var rowErr error
+		for i, value := range record {
+			switch columns[i] {
+			case "group":
+				song.GroupName = value
+			case "song":
+				song.SongName = value
+			case "release_date":
+				song.ReleaseDate = value
+			case "text":
+				song.Text = value
+			case "link":
+				song.Link = value
+			case "allow_duplicate":
+				if value != "" {
+					if song.AllowDuplicate, err = strconv.ParseBool(value); err != nil {
+						rowErr = fmt.Errorf("invalid allow_duplicate value %q", value)
+					}
+				}
+			case "explicit":
+				if value != "" {
+					if song.Explicit, err = strconv.ParseBool(value); err != nil {
+						rowErr = fmt.Errorf("invalid explicit value %q", value)
+					}
+				}
+			}
+		}
+		if err := fn(line, song, rowErr); err != nil {
+			return err
+		}
+	}
+}
+
+func readNDJSON(r io.Reader, fn RowFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var song entities.Song
+		err := json.Unmarshal([]byte(data), &song)
+		if err != nil {
+			err = fmt.Errorf("invalid JSON: %w", err)
+		}
+		if err := fn(line, song, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,251 @@
+package songio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/entities"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportColumns lists the columns that can be exported, in default order.
+var ExportColumns = []string{"id", "group", "song", "release_date", "link", "text", "allow_duplicate", "explicit"}
+
+var defaultExportColumns = []string{"id", "group", "song", "release_date", "link", "text"}
+
+// xlsxMaxCellLength is the longest value a spreadsheet cell can hold.
+const xlsxMaxCellLength = 32767
+
+var contentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ContentType returns the media type of an export format and whether the
+// format is supported.
+func ContentType(format string) (string, bool) {
+	contentType, ok := contentTypes[format]
+	return contentType, ok
+}
+
+// Columns resolves a comma-separated column selection. An empty selection
+// means the default columns; withText=false drops the lyrics either way.
+func Columns(selection string, withText bool) ([]string, error) {
+	columns := defaultExportColumns
+	if selection != "" {
+		columns = nil
+		seen := map[string]bool{}
+		for _, name := range strings.Split(selection, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			column, ok := csvColumns[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown column %q", name)
+			}
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	if withText {
+		return columns, nil
+	}
+	filtered := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column != "text" {
+			filtered = append(filtered, column)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return filtered, nil
+}
+
+// Writer encodes songs one at a time. Close must be called to flush
+// buffered output.
+type Writer interface {
+	Write(song entities.Song) error
+	Close() error
+}
+
+func NewWriter(w io.Writer, format string, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, columns: columns}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+func columnValue(song entities.Song, column string) interface{} {
+	switch column {
+	case "id":
+		return song.ID
+	case "group":
+		return song.GroupName
+	case "song":
+		return song.SongName
+	case "release_date":
+		return song.ReleaseDate
+	case "text":
+		return song.Text
+	case "link":
+		return song.Link
+	case "allow_duplicate":
+		return song.AllowDuplicate
+	case "explicit":
+		return song.Explicit
+	}
+	return nil
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	writer := &csvWriter{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+	if err := writer.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (c *csvWriter) Write(song entities.Song) error {
+	for i, column := range c.columns {
+		switch value := columnValue(song, column).(type) {
+		case int:
+			c.record[i] = strconv.Itoa(value)
+		case bool:
+			c.record[i] = strconv.FormatBool(value)
+		default:
+			c.record[i] = fmt.Sprint(value)
+		}
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	w       io.Writer
+	columns []string
+	buf     bytes.Buffer
+}
+
+// Write emits the selected columns as a JSON object, keeping column order.
+func (n *ndjsonWriter) Write(song entities.Song) error {
+	n.buf.Reset()
+	n.buf.WriteByte('{')
+	for i, column := range n.columns {
+		if i > 0 {
+			n.buf.WriteByte(',')
+		}
+		value, err := json.Marshal(columnValue(song, column))
+		if err != nil {
+			return err
+		}
+		n.buf.WriteString(strconv.Quote(column))
+		n.buf.WriteByte(':')
+		n.buf.Write(value)
+	}
+	n.buf.WriteString("}\n")
+	_, err := n.w.Write(n.buf.Bytes())
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter uses excelize's stream writer, which spills rows to a temporary
+// file instead of building the sheet in memory. The workbook is written to w
+// on Close.
+type xlsxWriter struct {
+	w       io.Writer
+	file    *excelize.File
+	stream  *excelize.StreamWriter
+	columns []string
+	row     int
+	values  []interface{}
+}
+
+func newXLSXWriter(w io.Writer, columns []string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	writer := &xlsxWriter{w: w, file: file, stream: stream, columns: columns, row: 1, values: make([]interface{}, len(columns))}
+	for i, column := range columns {
+		writer.values[i] = column
+	}
+	if err := writer.writeRow(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (x *xlsxWriter) Write(song entities.Song) error {
+	for i, column := range x.columns {
+		value := columnValue(song, column)
+		if text, ok := value.(string); ok {
+			value = truncateRunes(text, xlsxMaxCellLength)
+		}
+		x.values[i] = value
+	}
+	return x.writeRow()
+}
+
+func (x *xlsxWriter) writeRow() error {
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	x.row++
+	return x.stream.SetRow(cell, x.values)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.w)
+	return err
+}
+
+func truncateRunes(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == limit {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package textdiff
+
+import (
+	"strings"
+
+	"github.com/senyabanana/library-service/internal/entities"
+)
+
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Lines returns a line-level diff turning a into b, based on the longest
+// common subsequence of their lines.
+func Lines(a, b string) []entities.LineChange {
+	from := splitLines(a)
+	to := splitLines(b)
+
+	// lcs[i][j] is the LCS length of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := make([]entities.LineChange, 0, max(len(from), len(to)))
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			changes = append(changes, entities.LineChange{Op: OpEqual, Line: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changes = append(changes, entities.LineChange{Op: OpDelete, Line: from[i]})
+			i++
+		default:
+			changes = append(changes, entities.LineChange{Op: OpInsert, Line: to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		changes = append(changes, entities.LineChange{Op: OpDelete, Line: from[i]})
+	}
+	for ; j < len(to); j++ {
+		changes = append(changes, entities.LineChange{Op: OpInsert, Line: to[j]})
+	}
+	return changes
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
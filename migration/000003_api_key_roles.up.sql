@@ -0,0 +1,7 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'viewer';
+
+UPDATE api_keys SET role = CASE
+    WHEN 'admin' = ANY(scopes) THEN 'admin'
+    WHEN 'write' = ANY(scopes) THEN 'editor'
+    ELSE 'viewer'
+END;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS song_revisions (
+    song_id INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    revision INT NOT NULL,
+    group_name VARCHAR(255) NOT NULL,
+    song_name VARCHAR(255) NOT NULL,
+    release_date DATE NOT NULL,
+    text TEXT NOT NULL,
+    link TEXT NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, revision)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS songs_dedup_key_idx;
+DROP TABLE IF EXISTS song_duplicate_reports;
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS song_key,
+    DROP COLUMN IF EXISTS group_key,
+    DROP COLUMN IF EXISTS allow_duplicate;
+DROP FUNCTION IF EXISTS song_dedup_key(TEXT);
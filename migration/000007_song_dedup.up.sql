@@ -0,0 +1,52 @@
+-- Normalizes a group or song name for duplicate detection: case, runs of
+-- whitespace, diacritics on Latin letters and a leading "The " are ignored.
+CREATE OR REPLACE FUNCTION song_dedup_key(value TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(
+        btrim(regexp_replace(
+            regexp_replace(normalize(lower(value), NFD), '([a-z])[\u0300-\u036f]+', '\1', 'g'),
+            '\s+', ' ', 'g')),
+        '^the ', '')
+$$ LANGUAGE SQL IMMUTABLE STRICT;
+
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS allow_duplicate BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS group_key TEXT GENERATED ALWAYS AS (song_dedup_key(group_name)) STORED,
+    ADD COLUMN IF NOT EXISTS song_key TEXT GENERATED ALWAYS AS (song_dedup_key(song_name)) STORED;
+
+-- Songs that already collide are reported here and marked as allowed
+-- duplicates, so the unique index can be built; they can be merged later.
+-- The mark is permanent: the dedup check ignores these songs until they are
+-- merged or allow_duplicate is cleared by hand, and this table is the only
+-- record of why they were marked.
+CREATE TABLE IF NOT EXISTS song_duplicate_reports (
+    song_id INT PRIMARY KEY REFERENCES songs(id) ON DELETE CASCADE,
+    duplicate_of INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO song_duplicate_reports (song_id, duplicate_of)
+SELECT id, keeper FROM (
+    SELECT id,
+        first_value(id) OVER (PARTITION BY group_key, song_key ORDER BY id) AS keeper,
+        row_number() OVER (PARTITION BY group_key, song_key ORDER BY id) AS rn
+    FROM songs
+    WHERE deleted_at IS NULL AND NOT allow_duplicate
+) ranked
+WHERE rn > 1
+ON CONFLICT (song_id) DO NOTHING;
+
+UPDATE songs SET allow_duplicate = TRUE WHERE id IN (SELECT song_id FROM song_duplicate_reports);
+
+DO $$
+DECLARE
+    conflicts INT;
+BEGIN
+    SELECT count(*) INTO conflicts FROM song_duplicate_reports;
+    IF conflicts > 0 THEN
+        RAISE WARNING '% existing duplicate songs reported in song_duplicate_reports', conflicts;
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS songs_dedup_key_idx ON songs (group_key, song_key)
+    WHERE NOT allow_duplicate AND deleted_at IS NULL;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS song_redirects (
+    old_id INT PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    merged_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS song_redirects_song_id_idx ON song_redirects (song_id);
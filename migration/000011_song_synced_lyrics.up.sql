@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS song_synced_lyrics (
+    song_id INT PRIMARY KEY REFERENCES songs (id) ON DELETE CASCADE,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    lines JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS songs_sync_original_lyrics ON songs;
+DROP FUNCTION IF EXISTS sync_original_lyrics();
+DROP TABLE IF EXISTS song_lyrics;
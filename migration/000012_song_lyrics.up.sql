@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS song_lyrics (
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    language VARCHAR(35) NOT NULL,
+    text TEXT NOT NULL,
+    is_original BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, language)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS song_lyrics_original_idx ON song_lyrics (song_id) WHERE is_original;
+
+-- The original version mirrors songs.text, which stays the place where the
+-- original lyrics are edited. Its language is unknown ("und") until set.
+INSERT INTO song_lyrics (song_id, language, text, is_original)
+SELECT id, 'und', text, TRUE FROM songs
+ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION sync_original_lyrics() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE song_lyrics SET text = NEW.text, updated_at = now()
+    WHERE song_id = NEW.id AND is_original;
+    IF NOT FOUND THEN
+        INSERT INTO song_lyrics (song_id, language, text, is_original) VALUES (NEW.id, 'und', NEW.text, TRUE);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS songs_sync_original_lyrics ON songs;
+CREATE TRIGGER songs_sync_original_lyrics
+    AFTER INSERT OR UPDATE OF text ON songs
+    FOR EACH ROW EXECUTE FUNCTION sync_original_lyrics();
@@ -0,0 +1,12 @@
+CREATE OR REPLACE FUNCTION sync_original_lyrics() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE song_lyrics SET text = NEW.text, updated_at = now()
+    WHERE song_id = NEW.id AND is_original;
+    IF NOT FOUND THEN
+        INSERT INTO song_lyrics (song_id, language, text, is_original) VALUES (NEW.id, 'und', NEW.text, TRUE);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE song_lyrics DROP COLUMN IF EXISTS sections;
@@ -0,0 +1,14 @@
+-- Parsed sections of the lyrics, filled in on first read. Changing the
+-- original through songs.text clears them.
+ALTER TABLE song_lyrics ADD COLUMN IF NOT EXISTS sections JSONB;
+
+CREATE OR REPLACE FUNCTION sync_original_lyrics() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE song_lyrics SET text = NEW.text, sections = NULL, updated_at = now()
+    WHERE song_id = NEW.id AND is_original;
+    IF NOT FOUND THEN
+        INSERT INTO song_lyrics (song_id, language, text, is_original) VALUES (NEW.id, 'und', NEW.text, TRUE);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
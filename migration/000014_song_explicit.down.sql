@@ -0,0 +1,3 @@
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS explicit_manual,
+    DROP COLUMN IF EXISTS explicit;
@@ -0,0 +1,3 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS explicit BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS explicit_manual BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE song_revisions DROP COLUMN IF EXISTS release_date_precision;
+ALTER TABLE songs DROP COLUMN IF EXISTS release_date_precision;
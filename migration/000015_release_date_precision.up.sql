@@ -0,0 +1,7 @@
+-- Release dates known only to the year or month are stored as the first day
+-- of that year or month.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS release_date_precision VARCHAR(5) NOT NULL DEFAULT 'day'
+    CHECK (release_date_precision IN ('year', 'month', 'day'));
+
+ALTER TABLE song_revisions ADD COLUMN IF NOT EXISTS release_date_precision VARCHAR(5) NOT NULL DEFAULT 'day'
+    CHECK (release_date_precision IN ('year', 'month', 'day'));
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS songs_sync_primary_link ON songs;
+DROP FUNCTION IF EXISTS sync_primary_link();
+DROP TABLE IF EXISTS song_links;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS song_links (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(10) NOT NULL DEFAULT 'unchecked' CHECK (status IN ('unchecked', 'alive', 'dead')),
+    http_status INT,
+    failures INT NOT NULL DEFAULT 0,
+    checked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (song_id, url)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS song_links_primary_idx ON song_links (song_id) WHERE is_primary;
+CREATE INDEX IF NOT EXISTS song_links_checked_at_idx ON song_links (checked_at NULLS FIRST);
+
+-- The primary link mirrors songs.link, which stays the place where it is
+-- edited: setting a link there makes it the primary one.
+INSERT INTO song_links (song_id, url, is_primary)
+SELECT id, link, TRUE FROM songs WHERE link <> ''
+ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION sync_primary_link() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE song_links SET is_primary = FALSE WHERE song_id = NEW.id AND is_primary AND url <> NEW.link;
+    IF NEW.link <> '' THEN
+        INSERT INTO song_links (song_id, url, is_primary) VALUES (NEW.id, NEW.link, TRUE)
+        ON CONFLICT (song_id, url) DO UPDATE SET is_primary = TRUE;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS songs_sync_primary_link ON songs;
+CREATE TRIGGER songs_sync_primary_link
+    AFTER INSERT OR UPDATE OF link ON songs
+    FOR EACH ROW EXECUTE FUNCTION sync_primary_link();
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS song_field_sources (
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    field VARCHAR(32) NOT NULL,
+    provider VARCHAR(64) NOT NULL,
+    enriched_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, field)
+);
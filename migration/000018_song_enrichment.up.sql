@@ -0,0 +1,25 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enriched_at TIMESTAMPTZ;
+
+UPDATE songs s SET enriched_at = f.enriched_at
+FROM (SELECT song_id, MAX(enriched_at) AS enriched_at FROM song_field_sources WHERE provider <> 'manual' GROUP BY song_id) f
+WHERE f.song_id = s.id;
+
+CREATE INDEX IF NOT EXISTS songs_enriched_at_idx ON songs (enriched_at) WHERE deleted_at IS NULL AND enriched_at IS NOT NULL;
+
+-- Values re-enrichment found upstream for fields that were edited by hand.
+-- They wait here for an editor instead of overwriting the edit.
+CREATE TABLE IF NOT EXISTS enrichment_reviews (
+    id SERIAL PRIMARY KEY,
+    song_id INT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    field VARCHAR(32) NOT NULL,
+    current_value TEXT NOT NULL,
+    proposed_value TEXT NOT NULL,
+    provider VARCHAR(64) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ,
+    resolved_by VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS enrichment_reviews_pending_idx ON enrichment_reviews (song_id, field) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS enrichment_reviews_status_idx ON enrichment_reviews (status, created_at);
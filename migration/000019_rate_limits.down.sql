@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rate_limit_counters;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS daily_quota;
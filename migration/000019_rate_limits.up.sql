@@ -0,0 +1,12 @@
+-- NULL uses the configured default quota, 0 means no quota.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_quota INT CHECK (daily_quota >= 0);
+
+-- Request counters of the Postgres rate limit store, one row per client,
+-- bucket and window.
+CREATE TABLE IF NOT EXISTS rate_limit_counters (
+    key VARCHAR(255) PRIMARY KEY,
+    count INT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_counters_expires_at_idx ON rate_limit_counters (expires_at);
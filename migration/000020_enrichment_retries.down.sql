@@ -0,0 +1,2 @@
+ALTER TABLE songs DROP COLUMN IF EXISTS enrichment_retry_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS enrichment_failures;
@@ -0,0 +1,4 @@
+-- Failed re-enrichment attempts back off, so that songs that keep failing
+-- do not hold up the rest of the stale songs.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrichment_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS enrichment_retry_at TIMESTAMPTZ;